@@ -0,0 +1,124 @@
+// Package clock is the server's notion of "now". It follows the wall clock
+// by default and can be frozen, set or advanced at runtime so that
+// time-dependent behavior (expiry, ID assignment delays, timestamps) can be
+// tested without waiting.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	mu       sync.RWMutex
+	offset   time.Duration // virtual minus wall time while running
+	frozen   bool
+	frozenAt time.Time
+
+	subscribers []chan struct{}
+)
+
+// State describes the clock for the admin API
+type State struct {
+	Now    time.Time
+	Frozen bool
+	Offset time.Duration
+}
+
+// Now returns the current virtual time
+func Now() time.Time {
+	mu.RLock()
+	defer mu.RUnlock()
+	return nowLocked()
+}
+
+func nowLocked() time.Time {
+	if frozen {
+		return frozenAt
+	}
+	return time.Now().Add(offset)
+}
+
+// Current returns the clock's state
+func Current() State {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	now := nowLocked()
+	return State{Now: now, Frozen: frozen, Offset: now.Sub(time.Now())}
+}
+
+// Freeze stops the clock at the current virtual time
+func Freeze() {
+	change(func() {
+		if !frozen {
+			frozenAt = nowLocked()
+			frozen = true
+		}
+	})
+}
+
+// Resume lets a frozen clock tick again from where it stopped
+func Resume() {
+	change(func() {
+		if frozen {
+			offset = time.Until(frozenAt)
+			frozen = false
+		}
+	})
+}
+
+// Set jumps to t; a frozen clock stays frozen at t
+func Set(t time.Time) {
+	change(func() {
+		if frozen {
+			frozenAt = t.Local()
+		} else {
+			offset = time.Until(t)
+		}
+	})
+}
+
+// Advance moves the clock forward by d (backwards when d is negative)
+func Advance(d time.Duration) {
+	change(func() {
+		if frozen {
+			frozenAt = frozenAt.Add(d)
+		} else {
+			offset += d
+		}
+	})
+}
+
+// Reset returns to the wall clock
+func Reset() {
+	change(func() {
+		offset = 0
+		frozen = false
+	})
+}
+
+// Subscribe returns a channel that receives a signal after every manual
+// change, so background workers can re-evaluate without waiting for a tick.
+func Subscribe() <-chan struct{} {
+	mu.Lock()
+	defer mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	subscribers = append(subscribers, ch)
+	return ch
+}
+
+func change(apply func()) {
+	mu.Lock()
+	apply()
+	subs := subscribers
+	mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,636 @@
+// Command replay re-sends traffic recorded in the request journal
+// (journal.jsonl) and reports where the responses differ from the recording.
+//
+//	go run ./cmd/replay [flags] journal.jsonl.2 journal.jsonl.1 journal.jsonl
+//
+// Files are replayed in the order given, so list rotated files oldest first.
+// Without -target the app runs in-process on a fresh in-memory database,
+// which makes a replay deterministic when combined with -clock.
+//
+// Credentials are redacted in the journal. A redacted APIKey is replaced by
+// -apikey; a redacted Bearer token by a fresh token of the master OAuth
+// client, which may use every coop and scope, so a recorded 403 can replay
+// as a success. Signed requests are signed again with a new timestamp and
+// nonce, using -signing-secret or, in-process, the coop's signing secret or
+// SIGNATURE_SECRET; against a -target without -signing-secret they keep
+// their recorded signature and are rejected as replays.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/journal"
+	"github.com/shyamsundaar/karino-mock-server/server"
+	"github.com/shyamsundaar/karino-mock-server/signing"
+)
+
+const defaultIgnore = "createdAt,updatedAt,created_at,updated_at,CreatedAt,UpdatedAt,CreatedDate,UpdatedDate"
+
+var timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+
+type options struct {
+	target    string
+	configDir string
+	dbDriver  string
+	apiKey    string
+	oauthID   string
+	signing   string
+	coopMap   map[string]string
+	timeShift string
+	setClock  bool
+	ignore    map[string]bool
+	delay     time.Duration
+	verbose   bool
+}
+
+func main() {
+	var (
+		opts    options
+		coopMap string
+		ignore  string
+	)
+
+	flag.StringVar(&opts.target, "target", "", "base URL of a running server (default: run the app in-process)")
+	flag.StringVar(&opts.configDir, "config", ".", "directory with app.env (in-process only)")
+	flag.StringVar(&opts.dbDriver, "db", "memory", "DB_DRIVER for the in-process app")
+	flag.StringVar(&opts.apiKey, "apikey", "", "APIKey to send in place of the redacted one (default: APIKey from app.env)")
+	flag.StringVar(&opts.oauthID, "oauth-client", "", "master OAuth client_id used to re-issue Bearer tokens (default: OAUTH_CLIENT_ID from app.env, or karino-mock)")
+	flag.StringVar(&opts.signing, "signing-secret", "", "secret to re-sign signed requests with (default in-process: the coop's signing secret or SIGNATURE_SECRET)")
+	flag.StringVar(&coopMap, "coop", "", "rewrite coop IDs, e.g. COOP019=COOP029,COOP020=COOP030")
+	flag.StringVar(&opts.timeShift, "time-shift", "", `shift timestamps in requests by a duration (e.g. 24h) or "auto" (recording start -> now)`)
+	flag.BoolVar(&opts.setClock, "clock", false, "set the server clock to each entry's (shifted) recorded time before sending it")
+	flag.StringVar(&ignore, "ignore", "", "extra JSON field names to ignore in response diffs (always ignored: "+defaultIgnore+")")
+	flag.DurationVar(&opts.delay, "delay", 0, "pause between requests")
+	flag.BoolVar(&opts.verbose, "v", false, "keep the in-process server's logs")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: replay [flags] journal.jsonl...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	var err error
+	if opts.coopMap, err = parseCoopMap(coopMap); err != nil {
+		log.Fatalln(err)
+	}
+	opts.ignore = map[string]bool{}
+	for _, f := range strings.Split(defaultIgnore+","+ignore, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			opts.ignore[f] = true
+		}
+	}
+
+	entries, err := readEntries(flag.Args())
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(entries) == 0 {
+		log.Fatalln("no journal entries to replay")
+	}
+
+	shift, err := resolveShift(opts.timeShift, entries[0].Time)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	r, err := newReplayer(&opts)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	diffs := 0
+	for _, e := range entries {
+		if e.Dropped {
+			fmt.Printf("⏭️  #%d %s %s skipped (connection was dropped)\n", e.ID, e.Method, e.Path)
+			continue
+		}
+
+		if opts.setClock {
+			if err := r.setClock(e.Time.Add(shift)); err != nil {
+				log.Fatalln("setting clock:", err)
+			}
+		}
+
+		if err := r.settleJobs(); err != nil {
+			log.Fatalln("settling jobs:", err)
+		}
+
+		status, body, err := r.send(&e, shift)
+		if err != nil {
+			diffs++
+			fmt.Printf("❌ #%d %s %s: %v\n", e.ID, e.Method, e.Path, err)
+			continue
+		}
+
+		problems := compare(&e, status, body, &opts)
+		if len(problems) == 0 {
+			fmt.Printf("✅ #%d %s %s %d\n", e.ID, e.Method, e.Path, status)
+		} else {
+			diffs++
+			fmt.Printf("❌ #%d %s %s\n", e.ID, e.Method, e.Path)
+			for _, p := range problems {
+				fmt.Printf("     %s\n", p)
+			}
+		}
+
+		if opts.delay > 0 {
+			time.Sleep(opts.delay)
+		}
+	}
+
+	if opts.setClock && opts.target != "" {
+		r.resetClock()
+	}
+
+	fmt.Printf("\n%d requests replayed, %d with differences\n", len(entries), diffs)
+	if diffs > 0 {
+		os.Exit(1)
+	}
+}
+
+// =======================
+// Input
+// =======================
+
+func readEntries(paths []string) ([]journal.Entry, error) {
+	var entries []journal.Entry
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, 1<<20), 8<<20)
+		for line := 1; sc.Scan(); line++ {
+			if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+				continue
+			}
+			var e journal.Entry
+			if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			entries = append(entries, e)
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+func parseCoopMap(raw string) (map[string]string, error) {
+	m := map[string]string{}
+	if raw == "" {
+		return m, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(from) == "" {
+			return nil, fmt.Errorf("invalid -coop pair %q (want OLD=NEW)", pair)
+		}
+		m[strings.TrimSpace(from)] = strings.TrimSpace(to)
+	}
+	return m, nil
+}
+
+func resolveShift(raw string, start time.Time) (time.Duration, error) {
+	switch raw {
+	case "":
+		return 0, nil
+	case "auto":
+		return time.Since(start).Round(time.Second), nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid -time-shift %q: %w", raw, err)
+	}
+	return d, nil
+}
+
+// =======================
+// Rewriting
+// =======================
+
+func rewriteCoops(s string, m map[string]string) string {
+	for from, to := range m {
+		s = regexp.MustCompile(`\b`+regexp.QuoteMeta(from)+`\b`).ReplaceAllString(s, to)
+	}
+	return s
+}
+
+func shiftTimestamps(s string, d time.Duration) string {
+	if d == 0 {
+		return s
+	}
+	return timestampPattern.ReplaceAllStringFunc(s, func(ts string) string {
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return ts
+		}
+		layout := time.RFC3339
+		if strings.Contains(ts, ".") {
+			layout = time.RFC3339Nano
+		}
+		return t.Add(d).Format(layout)
+	})
+}
+
+func rewriteQuery(raw string, opts *options, shift time.Duration) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return rewriteCoops(raw, opts.coopMap)
+	}
+	for k, vs := range values {
+		for i, v := range vs {
+			vs[i] = shiftTimestamps(rewriteCoops(v, opts.coopMap), shift)
+		}
+		values[k] = vs
+	}
+	return values.Encode()
+}
+
+// =======================
+// Sending
+// =======================
+
+type replayer struct {
+	opts   *options
+	app    *fiber.App
+	client *http.Client
+}
+
+func newReplayer(opts *options) (*replayer, error) {
+	r := &replayer{opts: opts}
+
+	if opts.target != "" {
+		r.client = &http.Client{Timeout: 60 * time.Second}
+		opts.target = strings.TrimRight(opts.target, "/")
+		if opts.oauthID == "" {
+			opts.oauthID = "karino-mock"
+		}
+		return r, nil
+	}
+
+	config, err := initializers.LoadConfig(opts.configDir)
+	if err != nil {
+		return nil, err
+	}
+	config.DBDriver = opts.dbDriver
+	config.JournalPath = "off"
+	if !opts.verbose {
+		config.DBLogLevel = "silent"
+		log.SetOutput(io.Discard)
+	}
+	initializers.AppConfig = config
+
+	server.Setup(&config)
+
+	if opts.apiKey == "" {
+		opts.apiKey = config.ApiKey
+	}
+	if opts.oauthID == "" {
+		opts.oauthID = initializers.OAuthClientID()
+	}
+	r.app = server.New(server.Config{DisableRequestLog: !opts.verbose})
+	return r, nil
+}
+
+func (r *replayer) do(req *http.Request) (*http.Response, error) {
+	if r.app != nil {
+		return r.app.Test(req, -1)
+	}
+	return r.client.Do(req)
+}
+
+func (r *replayer) base() string {
+	if r.opts.target == "" {
+		return "http://localhost"
+	}
+	return r.opts.target
+}
+
+func (r *replayer) send(e *journal.Entry, shift time.Duration) (int, []byte, error) {
+	u := r.base() + rewriteCoops(e.Path, r.opts.coopMap)
+	if q := rewriteQuery(e.Query, r.opts, shift); q != "" {
+		u += "?" + q
+	}
+
+	body := shiftTimestamps(rewriteCoops(e.RequestBody, r.opts.coopMap), shift)
+
+	req, err := http.NewRequest(e.Method, u, strings.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	for k, v := range e.RequestHeaders {
+		switch strings.ToLower(k) {
+		case "host", "content-length", "connection":
+			continue
+		}
+		if v == "[REDACTED]" {
+			switch {
+			case strings.EqualFold(k, "APIKey"):
+				v = r.opts.apiKey
+			case strings.EqualFold(k, fiber.HeaderAuthorization):
+				token, err := r.accessToken()
+				if err != nil {
+					return 0, nil, fmt.Errorf("issuing a bearer token: %w", err)
+				}
+				v = "Bearer " + token
+			default:
+				continue
+			}
+		}
+		req.Header.Set(k, v)
+	}
+
+	if req.Header.Get(signing.HeaderSignature) != "" {
+		r.sign(req, []byte(body))
+	}
+
+	resp, err := r.do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, err
+}
+
+// accessToken issues a token for the master OAuth client, standing in for
+// the redacted one of the recording
+func (r *replayer) accessToken() (string, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest(http.MethodPost, r.base()+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(r.opts.oauthID, r.opts.apiKey)
+
+	resp, err := r.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK || out.AccessToken == "" {
+		return "", fmt.Errorf("/oauth/token returned %d", resp.StatusCode)
+	}
+	return out.AccessToken, nil
+}
+
+// signingSecret is the secret the server checks requests for coopId with.
+// It is only known in-process unless -signing-secret is given.
+func (r *replayer) signingSecret(coopId string) string {
+	if r.opts.signing != "" || r.app == nil {
+		return r.opts.signing
+	}
+	if coop, ok := initializers.LookupCooperative(coopId); ok && coop.SigningSecret != "" {
+		return coop.SigningSecret
+	}
+	return initializers.AppConfig.SignatureSecret
+}
+
+// sign replaces a recorded signature, whose nonce the server has seen and
+// whose timestamp has aged, with a new one
+func (r *replayer) sign(req *http.Request, body []byte) {
+	secret := r.signingSecret(coopIDFromPath(req.URL.Path))
+	if secret == "" {
+		return
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonceHex := hex.EncodeToString(nonce)
+
+	req.Header.Set(signing.HeaderTimestamp, timestamp)
+	req.Header.Set(signing.HeaderNonce, nonceHex)
+	req.Header.Set(signing.HeaderSignature, signing.Sign(secret, req.Method, req.URL.RequestURI(), timestamp, nonceHex, body))
+}
+
+// coopIDFromPath finds the coop of a /customers/:coopId or /vendors/:coopId path
+func coopIDFromPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(parts)-1; i++ {
+		if parts[i] == "customers" || parts[i] == "vendors" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// admin calls the server's admin API and decodes the JSON answer into out
+func (r *replayer) admin(method, path, body string, out interface{}) (int, error) {
+	req, err := http.NewRequest(method, r.base()+path, strings.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("APIKey", r.opts.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func (r *replayer) setClock(t time.Time) error {
+	status, err := r.admin(http.MethodPost, "/admin/clock/set", fmt.Sprintf(`{"time":%q}`, t.UTC().Format(time.RFC3339Nano)), nil)
+	if err == nil && status != http.StatusOK {
+		err = fmt.Errorf("/admin/clock/set returned %d", status)
+	}
+	return err
+}
+
+func (r *replayer) resetClock() {
+	if _, err := r.admin(http.MethodPost, "/admin/clock/reset", "", nil); err != nil {
+		fmt.Fprintln(os.Stderr, "resetting clock:", err)
+	}
+}
+
+// settleJobs runs every ID assignment job that is due at the server's
+// current time, so replayed responses don't depend on worker timing.
+func (r *replayer) settleJobs() error {
+	var now struct {
+		Data struct {
+			Now time.Time `json:"now"`
+		} `json:"data"`
+	}
+	if _, err := r.admin(http.MethodGet, "/admin/clock", "", &now); err != nil {
+		return err
+	}
+
+	for round := 0; round < 40; round++ {
+		var list struct {
+			Data []struct {
+				ID     uint      `json:"id"`
+				Status string    `json:"status"`
+				RunAt  time.Time `json:"runAt"`
+			} `json:"data"`
+		}
+		if _, err := r.admin(http.MethodGet, "/admin/jobs?status=PENDING&perPage=100", "", &list); err != nil {
+			return err
+		}
+		var running struct {
+			Data []struct{} `json:"data"`
+		}
+		if _, err := r.admin(http.MethodGet, "/admin/jobs?status=RUNNING&perPage=1", "", &running); err != nil {
+			return err
+		}
+
+		due := 0
+		for i := len(list.Data) - 1; i >= 0; i-- {
+			job := list.Data[i]
+			if job.RunAt.After(now.Data.Now) {
+				continue
+			}
+			due++
+			if _, err := r.admin(http.MethodPost, fmt.Sprintf("/admin/jobs/%d/run", job.ID), "", nil); err != nil {
+				return err
+			}
+		}
+
+		if due == 0 && len(running.Data) == 0 {
+			return nil
+		}
+		// A worker may have claimed a job first; wait for it to finish
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
+
+// =======================
+// Diffing
+// =======================
+
+func compare(e *journal.Entry, status int, body []byte, opts *options) []string {
+	var problems []string
+	if status != e.Status {
+		problems = append(problems, fmt.Sprintf("status: recorded %d, got %d", e.Status, status))
+	}
+
+	recorded := rewriteCoops(e.ResponseBody, opts.coopMap)
+
+	var want, got interface{}
+	if json.Unmarshal([]byte(recorded), &want) != nil || json.Unmarshal(body, &got) != nil {
+		if recorded != string(body) {
+			problems = append(problems, fmt.Sprintf("body: recorded %q, got %q", recorded, body))
+		}
+		return problems
+	}
+
+	diffJSON("$", want, got, opts.ignore, &problems)
+	return problems
+}
+
+func diffJSON(path string, want, got interface{}, ignore map[string]bool, out *[]string) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			*out = append(*out, fmt.Sprintf("%s: recorded object, got %s", path, short(got)))
+			return
+		}
+
+		keys := map[string]bool{}
+		for k := range w {
+			keys[k] = true
+		}
+		for k := range g {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			if ignore[k] {
+				continue
+			}
+			wv, inW := w[k]
+			gv, inG := g[k]
+			switch {
+			case !inG:
+				*out = append(*out, fmt.Sprintf("%s.%s: missing (recorded %s)", path, k, short(wv)))
+			case !inW:
+				*out = append(*out, fmt.Sprintf("%s.%s: unexpected %s", path, k, short(gv)))
+			default:
+				diffJSON(path+"."+k, wv, gv, ignore, out)
+			}
+		}
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			*out = append(*out, fmt.Sprintf("%s: recorded array, got %s", path, short(got)))
+			return
+		}
+		if len(w) != len(g) {
+			*out = append(*out, fmt.Sprintf("%s: recorded %d items, got %d", path, len(w), len(g)))
+		}
+		for i := 0; i < len(w) && i < len(g); i++ {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], ignore, out)
+		}
+
+	default:
+		if !reflect.DeepEqual(want, got) {
+			*out = append(*out, fmt.Sprintf("%s: recorded %s, got %s", path, short(want), short(got)))
+		}
+	}
+}
+
+func short(v interface{}) string {
+	b, _ := json.Marshal(v)
+	if len(b) > 80 {
+		return string(b[:77]) + "..."
+	}
+	return string(b)
+}
@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/apikeys"
+	"gorm.io/gorm"
+)
+
+// findAPIKey loads the API key of the path. When there is none it writes
+// the error response and returns false.
+func findAPIKey(c *fiber.Ctx, key *apikeys.APIKey) (bool, error) {
+	id, _ := strconv.ParseUint(c.Params("keyId"), 10, 64)
+	err := initializers.DB.First(key, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "API key not found",
+		})
+	}
+	if err != nil {
+		return false, c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	return true, nil
+}
+
+// ListAPIKeysHandler handles GET /admin/apikeys
+// @Summary      List tenant API keys
+// @Description  Keys are listed by prefix; the plaintext is only shown on creation
+// @Tags         admin
+// @Produce      json
+// @Param        coopId  query  string  false  "Only keys permitted for this cooperative"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/apikeys [get]
+func ListAPIKeysHandler(c *fiber.Ctx) error {
+	var list []apikeys.APIKey
+	if err := initializers.DB.Order("id").Find(&list).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	// Coops is a JSON column, filter in Go rather than per-driver JSON SQL
+	if coopId := c.Query("coopId"); coopId != "" {
+		filtered := list[:0]
+		for _, k := range list {
+			if k.AllowsCoop(coopId) {
+				filtered = append(filtered, k)
+			}
+		}
+		list = filtered
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// CreateAPIKeyHandler handles POST /admin/apikeys
+// @Summary      Issue a tenant API key
+// @Description  The response carries the plaintext key; it cannot be retrieved again
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        key  body      apikeys.CreateAPIKeySchema  true  "Key"
+// @Success      201  {object}  map[string]interface{}
+// @Router       /admin/apikeys [post]
+func CreateAPIKeyHandler(c *fiber.Ctx) error {
+	var payload apikeys.CreateAPIKeySchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	key := apikeys.APIKey{
+		Name:      payload.Name,
+		Coops:     payload.Coops,
+		Scopes:    payload.Scopes,
+		ExpiresAt: payload.ExpiresAt,
+	}
+	if err := key.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	plaintext, err := apikeys.Generate()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	key.SetKey(plaintext)
+
+	if err := initializers.DB.Create(&key).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"key":     plaintext,
+		"data":    key,
+	})
+}
+
+// GetAPIKeyHandler handles GET /admin/apikeys/:keyId
+// @Summary      Get a tenant API key
+// @Tags         admin
+// @Produce      json
+// @Param        keyId  path  int  true  "Key ID"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/apikeys/{keyId} [get]
+func GetAPIKeyHandler(c *fiber.Ctx) error {
+	var key apikeys.APIKey
+	if found, err := findAPIKey(c, &key); !found {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// UpdateAPIKeyHandler handles PUT /admin/apikeys/:keyId
+// @Summary      Update a tenant API key
+// @Description  Change the name, coops, scopes or expiry, or revoke the key; omitted fields are kept
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        keyId  path      int                         true  "Key ID"
+// @Param        key    body      apikeys.UpdateAPIKeySchema  true  "Changes"
+// @Success      200    {object}  map[string]interface{}
+// @Router       /admin/apikeys/{keyId} [put]
+func UpdateAPIKeyHandler(c *fiber.Ctx) error {
+	var key apikeys.APIKey
+	if found, err := findAPIKey(c, &key); !found {
+		return err
+	}
+
+	var payload apikeys.UpdateAPIKeySchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if payload.Name != nil {
+		key.Name = *payload.Name
+	}
+	if payload.Coops != nil {
+		key.Coops = payload.Coops
+	}
+	if payload.Scopes != nil {
+		key.Scopes = payload.Scopes
+	}
+	if payload.ExpiresAt != nil {
+		key.ExpiresAt = payload.ExpiresAt
+	}
+	if payload.Revoked != nil {
+		key.Revoked = *payload.Revoked
+	}
+	if err := key.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if err := initializers.DB.Save(&key).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// RevokeAPIKeyHandler handles POST /admin/apikeys/:keyId/revoke
+// @Summary      Revoke a tenant API key
+// @Tags         admin
+// @Produce      json
+// @Param        keyId  path  int  true  "Key ID"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/apikeys/{keyId}/revoke [post]
+func RevokeAPIKeyHandler(c *fiber.Ctx) error {
+	var key apikeys.APIKey
+	if found, err := findAPIKey(c, &key); !found {
+		return err
+	}
+
+	if err := initializers.DB.Model(&key).Update("revoked", true).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// DeleteAPIKeyHandler handles DELETE /admin/apikeys/:keyId
+// @Summary      Delete a tenant API key
+// @Tags         admin
+// @Param        keyId  path  int  true  "Key ID"
+// @Success      204
+// @Router       /admin/apikeys/{keyId} [delete]
+func DeleteAPIKeyHandler(c *fiber.Ctx) error {
+	id, _ := strconv.ParseUint(c.Params("keyId"), 10, 64)
+
+	res := initializers.DB.Delete(&apikeys.APIKey{}, id)
+	if res.Error != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": res.Error.Error(),
+		})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "API key not found",
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
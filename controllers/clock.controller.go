@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/clock"
+)
+
+type SetClockSchema struct {
+	Time string `json:"time" example:"2026-01-01T00:00:00Z"`
+}
+
+type AdvanceClockSchema struct {
+	Duration string `json:"duration" example:"1h30m"`
+}
+
+func clockResponse(c *fiber.Ctx) error {
+	state := clock.Current()
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"now":           state.Now.UTC().Format(time.RFC3339Nano),
+			"frozen":        state.Frozen,
+			"offsetSeconds": int64(state.Offset.Round(time.Second) / time.Second),
+		},
+	})
+}
+
+// GetClockHandler handles GET /admin/clock
+// @Summary      Get the server clock
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/clock [get]
+func GetClockHandler(c *fiber.Ctx) error {
+	return clockResponse(c)
+}
+
+// FreezeClockHandler handles POST /admin/clock/freeze
+// @Summary      Freeze the server clock
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/clock/freeze [post]
+func FreezeClockHandler(c *fiber.Ctx) error {
+	clock.Freeze()
+	return clockResponse(c)
+}
+
+// ResumeClockHandler handles POST /admin/clock/resume
+// @Summary      Let a frozen server clock tick again
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/clock/resume [post]
+func ResumeClockHandler(c *fiber.Ctx) error {
+	clock.Resume()
+	return clockResponse(c)
+}
+
+// SetClockHandler handles POST /admin/clock/set
+// @Summary      Set the server clock
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        body  body      SetClockSchema  true  "RFC3339 time"
+// @Success      200   {object}  map[string]interface{}
+// @Router       /admin/clock/set [post]
+func SetClockHandler(c *fiber.Ctx) error {
+	var payload SetClockSchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	t, err := time.Parse(time.RFC3339, payload.Time)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Invalid time format. Use ISO8601 (YYYY-MM-DDTHH:MM:SSZ)",
+		})
+	}
+
+	clock.Set(t)
+	return clockResponse(c)
+}
+
+// AdvanceClockHandler handles POST /admin/clock/advance
+// @Summary      Move the server clock forward
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        body  body      AdvanceClockSchema  true  "Go duration, e.g. 90s, 1h30m (negative moves back)"
+// @Success      200   {object}  map[string]interface{}
+// @Router       /admin/clock/advance [post]
+func AdvanceClockHandler(c *fiber.Ctx) error {
+	var payload AdvanceClockSchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	d, err := time.ParseDuration(payload.Duration)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Invalid duration. Use e.g. 90s, 15m or 1h30m",
+		})
+	}
+
+	clock.Advance(d)
+	return clockResponse(c)
+}
+
+// ResetClockHandler handles POST /admin/clock/reset
+// @Summary      Return the server clock to wall time
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/clock/reset [post]
+func ResetClockHandler(c *fiber.Ctx) error {
+	clock.Reset()
+	return clockResponse(c)
+}
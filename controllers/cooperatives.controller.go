@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/cooperatives"
+	"gorm.io/gorm"
+)
+
+// findCooperative loads the cooperative of the path. When there is none it
+// writes the error response and returns false.
+func findCooperative(c *fiber.Ctx, coop *cooperatives.Cooperative) (bool, error) {
+	err := initializers.DB.Where("coop_id = ?", c.Params("coopId")).First(coop).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Cooperative not found",
+		})
+	}
+	if err != nil {
+		return false, c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	return true, nil
+}
+
+// reloadCooperatives refreshes the isCoopAllowed cache after an admin write
+func reloadCooperatives() {
+	if err := initializers.ReloadCooperatives(initializers.DB); err != nil {
+		log.Printf("❌ Failed to reload cooperatives: %v", err)
+	}
+}
+
+// ListCooperativesHandler handles GET /admin/cooperatives
+// @Summary      List cooperatives
+// @Tags         admin
+// @Produce      json
+// @Param        enabled  query  bool  false  "Only enabled (true) or disabled (false) cooperatives"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/cooperatives [get]
+func ListCooperativesHandler(c *fiber.Ctx) error {
+	db := initializers.DB.Order("coop_id")
+	switch c.Query("enabled") {
+	case "true":
+		db = db.Where("enabled = ?", true)
+	case "false":
+		db = db.Where("enabled = ?", false)
+	}
+
+	var list []cooperatives.Cooperative
+	if err := db.Find(&list).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// CreateCooperativeHandler handles POST /admin/cooperatives
+// @Summary      Register a cooperative
+// @Description  The coop can call /spic_to_erp as soon as it is created (unless enabled is false)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        cooperative  body      cooperatives.CreateCooperativeSchema  true  "Cooperative"
+// @Success      201          {object}  map[string]interface{}
+// @Router       /admin/cooperatives [post]
+func CreateCooperativeHandler(c *fiber.Ctx) error {
+	var payload cooperatives.CreateCooperativeSchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	coop := cooperatives.Cooperative{
+		CoopID:   payload.CoopID,
+		Name:     payload.Name,
+		Country:  payload.Country,
+		Currency: payload.Currency,
+		TaxRate:  payload.TaxRate,
+		Enabled:  payload.Enabled == nil || *payload.Enabled,
+
+		SigningSecret: payload.SigningSecret,
+	}
+	coop.Normalize()
+	if err := coop.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+	// The cooperative's ID format overrides are already configured
+	if err := initializers.ValidateIDFormats([]string{coop.CoopID}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	var count int64
+	initializers.DB.Model(&cooperatives.Cooperative{}).Where("coop_id = ?", coop.CoopID).Count(&count)
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Cooperative " + coop.CoopID + " already exists",
+		})
+	}
+
+	if err := initializers.DB.Create(&coop).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	reloadCooperatives()
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    coop,
+	})
+}
+
+// GetCooperativeHandler handles GET /admin/cooperatives/:coopId
+// @Summary      Get a cooperative
+// @Tags         admin
+// @Produce      json
+// @Param        coopId  path  string  true  "Cooperative ID"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/cooperatives/{coopId} [get]
+func GetCooperativeHandler(c *fiber.Ctx) error {
+	var coop cooperatives.Cooperative
+	if found, err := findCooperative(c, &coop); !found {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    coop,
+	})
+}
+
+// UpdateCooperativeHandler handles PUT /admin/cooperatives/:coopId
+// @Summary      Update a cooperative
+// @Description  Omitted fields are kept; set enabled to false to reject the coop's requests
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        coopId       path      string                                true  "Cooperative ID"
+// @Param        cooperative  body      cooperatives.UpdateCooperativeSchema  true  "Changes"
+// @Success      200          {object}  map[string]interface{}
+// @Router       /admin/cooperatives/{coopId} [put]
+func UpdateCooperativeHandler(c *fiber.Ctx) error {
+	var coop cooperatives.Cooperative
+	if found, err := findCooperative(c, &coop); !found {
+		return err
+	}
+
+	var payload cooperatives.UpdateCooperativeSchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if payload.Name != nil {
+		coop.Name = *payload.Name
+	}
+	if payload.Country != nil {
+		coop.Country = *payload.Country
+	}
+	if payload.Currency != nil {
+		coop.Currency = *payload.Currency
+	}
+	if payload.TaxRate != nil {
+		coop.TaxRate = *payload.TaxRate
+	}
+	if payload.Enabled != nil {
+		coop.Enabled = *payload.Enabled
+	}
+	if payload.SigningSecret != nil {
+		coop.SigningSecret = *payload.SigningSecret
+	}
+	coop.Normalize()
+	if err := coop.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if err := initializers.DB.Save(&coop).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	reloadCooperatives()
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    coop,
+	})
+}
+
+// DeleteCooperativeHandler handles DELETE /admin/cooperatives/:coopId
+// @Summary      Remove a cooperative
+// @Description  The coop's farmers and orders are kept; prefer disabling it to keep its metadata
+// @Tags         admin
+// @Param        coopId  path  string  true  "Cooperative ID"
+// @Success      204
+// @Router       /admin/cooperatives/{coopId} [delete]
+func DeleteCooperativeHandler(c *fiber.Ctx) error {
+	res := initializers.DB.Where("coop_id = ?", c.Params("coopId")).Delete(&cooperatives.Cooperative{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": res.Error.Error(),
+		})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Cooperative not found",
+		})
+	}
+	reloadCooperatives()
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
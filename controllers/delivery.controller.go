@@ -2,10 +2,11 @@ package controllers
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"math"
-	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	// "log"
@@ -14,96 +15,26 @@ import (
 	"github.com/google/uuid"
 
 	// "github.com/shyamsundaar/karino-mock-server/models/delivery"
-	// "gorm.io/gorm"
+	"gorm.io/gorm"
 	// "github.com/gin-gonic/gin"
 	"context"
 
+	"github.com/shyamsundaar/karino-mock-server/clock"
 	"github.com/shyamsundaar/karino-mock-server/initializers"
 	"github.com/shyamsundaar/karino-mock-server/models/delivery"
 	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
 	"github.com/shyamsundaar/karino-mock-server/query"
 )
 
 func GenerateNextDeliveryDocumentCode(
 	ctx context.Context,
 	q *query.Query,
+	coopId string,
 ) (string, error) {
 
-	dd := q.CreateDeliveryDocuments.WithContext(ctx)
-
-	last, err := dd.
-		Where(q.CreateDeliveryDocuments.DeliveryDocumentCode.Neq("")).
-		Order(q.CreateDeliveryDocuments.Id.Desc()).
-		First()
-
-	next := 1
-	if err == nil && last.DeliveryDocumentCode != "" {
-		re := regexp.MustCompile(`\d+$`)
-		if m := re.FindString(last.DeliveryDocumentCode); m != "" {
-			n, _ := strconv.Atoi(m)
-			next = n + 1
-		}
-	}
-	return fmt.Sprintf("GT2 2025/%d", next), nil
-}
-
-func GenerateAndSetNextERPItemIdGen(
-	ctx context.Context,
-	q *query.Query,
-	ErpSalesOrderCode uint,
-) (string, error) {
-
-	so := q.SalesOrder.WithContext(ctx)
-
-	// 1. Fetch current sales order row
-	row, err := so.
-		Where(q.SalesOrder.ID.Eq(ErpSalesOrderCode)).
-		First()
-	if err != nil {
-		return "", err
-	}
-
-	// 2. If already generated → return
-	if row.ErpSalesOrderCode != "" {
-		return row.ErpSalesOrderCode, nil
-	}
-
-	next := 1
-
-	last, err := so.
-		Where(q.SalesOrder.ErpSalesOrderCode.Neq("")).
-		Order(q.SalesOrder.ID.Desc()).
-		First()
-
-	if err == nil && last.ErpSalesOrderCode != "" {
-		re := regexp.MustCompile(`\d+$`)
-		if m := re.FindString(last.ErpSalesOrderCode); m != "" {
-			n, _ := strconv.Atoi(m)
-			next = n + 1
-		}
-	}
-
-	newErpSalesOrderCode := fmt.Sprintf("ECL 2025/%d", next)
-
-	// 5. Business delay
-	time.Sleep(time.Duration(initializers.AppConfig.SalesTimeSeconds) * time.Second)
-
-	// 6. Update ONLY if still empty (race-condition safe)
-	_, err = so.
-		Where(
-			q.SalesOrder.ID.Eq(ErpSalesOrderCode),
-			q.SalesOrder.ErpSalesOrderCode.Eq(""),
-		).
-		UpdateColumnSimple(
-			q.SalesOrder.ErpSalesOrderCode.Value(newErpSalesOrderCode),
-			q.SalesOrder.UpdatedAt.Value(time.Now()),
-		)
-
-	if err != nil {
-		return "", err
-	}
-
-	return newErpSalesOrderCode, nil
+	// Code from the coop's template (default GT2 2025/n)
+	return GenerateERPIdentifier(ctx, initializers.DeliveryDocumentCodeFormat, sequences.DeliveryDocumentCode, coopId)
 }
 
 func GenerateNextDeliveryDocumentID() string {
@@ -120,8 +51,20 @@ func generate9DigitID() string {
 const (
 	StatusExpired    = "EXPIRED"
 	StatusNotExpired = "NOT EXPIRED"
+	StatusVoided     = "VOIDED"
 )
 
+// parseDeliveryStatusFilter validates the status query of the delivery
+// document lists. An empty filter matches every status.
+func parseDeliveryStatusFilter(c *fiber.Ctx) (string, bool) {
+	status := strings.ToUpper(strings.TrimSpace(c.Query("status")))
+	switch status {
+	case "", StatusExpired, StatusNotExpired, StatusVoided:
+		return status, true
+	}
+	return "", false
+}
+
 func ComputeExpirationStatus(
 	createdAt *time.Time,
 	expirationSeconds int,
@@ -135,78 +78,37 @@ func ComputeExpirationStatus(
 		time.Duration(expirationSeconds) * time.Second,
 	)
 
-	if time.Now().After(expirationTime) {
+	if clock.Now().After(expirationTime) {
 		return StatusExpired
 	}
 
 	return StatusNotExpired
 }
 
-// CreateCustomerDeliveryDocumentDetailsHandler handles POST /spic_to_erp/customers/:coopId/salesorders/deliverydocuments
-// @Summary      Create deliverydocuments details for a sales order
-// @Description  Create deliverydocuments details for a sales order
-// @Tags         deliverydocuments
-// @Accept       json
-// @Produce      json
-// @Param        coopId path      string  true   " "
-// @Param        detail  body      delivery.CreateDeliveryDocumentSchema    true  "Create delivery document Payload"
-// @Success      200    {object}  delivery.CreateDeliveryDocumentSuccessResponse
-// @Router       /spic_to_erp/customers/{coopId}/salesorders/deliverydocuments [post]
-func CreateCustomerDeliveryDocumentDetailsHandler(c *fiber.Ctx) error {
-	coopId := c.Params("coopId")
-
-	var payload *delivery.CreateDeliveryDocumentSchema
-	var salesOrder sales.SalesOrder
-	var deliverydocument delivery.CreateDeliveryDocuments
+// errDeliveryQuantityConflict means an item's open quantity changed while
+// delivery documents were being created for it
+var errDeliveryQuantityConflict = errors.New("open quantity changed")
 
-	//var salesOrderItems sales.SalesOrderItem
-	var salesOrderItemsList []sales.SalesOrderItem
-	if err := c.BodyParser(&payload); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "fail",
-			"message": err.Error(),
-		})
-	}
-
-	var noof_order_items int
-
-	salesErr := initializers.DB.Where("order_id = ? AND erp_sales_order_code = ?", payload.OrderID, payload.ErpSalesOrderCode).First(&salesOrder).Error
-	if salesErr != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"Message": "OrderId or SalesOrder not found "})
-	}
-
-	deliverydocumenterr := initializers.DB.Where("order_id = ?", payload.OrderID).First(&deliverydocument).Error
-
-	if deliverydocumenterr == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"Message": "Delivery Documents already Created for the OrderId"})
-	}
-
-	deliverydocumentserr := initializers.DB.Model(&salesOrder).Where("order_id = ?", payload.OrderID).Pluck("noof_order_items", &noof_order_items).Error
-
-	if deliverydocumentserr != nil {
-		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"Message": "Error fetching delivery documents"})
-	}
-
-	if payload.NoofDeliveryDocuments > noof_order_items {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"Message": "Number of delivery documents cannot be greater than number of order items"})
+// planDeliveryChunks splits the open items of an order into n documents,
+// each item delivering its whole open quantity
+func planDeliveryChunks(items []sales.SalesOrderItem, n int) ([][]sales.SalesOrderItem, string) {
+	if n <= 0 {
+		return nil, "NoofDeliveryDocuments must be greater than 0"
 	}
 
-	orderItemserr := initializers.DB.Where("order_id = ?", payload.OrderID).Find(&salesOrderItemsList).Error
-	if orderItemserr != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"Message": "No items found"})
+	var open []sales.SalesOrderItem
+	for _, item := range items {
+		if item.OpenQuantity() > 0 {
+			item.Quantity, item.DeliveredQuantity = item.OpenQuantity(), item.Quantity
+			open = append(open, item)
+		}
 	}
-
-	// Split salesOrderItemsList into N chunks
-	n := payload.NoofDeliveryDocuments
-	total := len(salesOrderItemsList)
-
-	if n <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "NoofDeliveryDocuments must be greater than 0",
-		})
+	if n > len(open) {
+		return nil, "Number of delivery documents cannot be greater than number of open order items"
 	}
 
 	// Calculate size of each chunk
+	total := len(open)
 	chunkSize := total / n
 	remainder := total % n
 
@@ -224,28 +126,80 @@ func CreateCustomerDeliveryDocumentDetailsHandler(c *fiber.Ctx) error {
 			end = total
 		}
 
-		chunks = append(chunks, salesOrderItemsList[start:end])
+		chunks = append(chunks, open[start:end])
 		start = end
 	}
-	now := time.Now().UTC()
+	return chunks, ""
+}
+
+// planDeliveryQuantities turns the per-document quantities of a request into
+// documents. Each line is a copy of its order item with Quantity set to the
+// quantity delivered on that document and DeliveredQuantity to the item's
+// total delivered quantity once the document exists.
+func planDeliveryQuantities(items []sales.SalesOrderItem, documents []delivery.DeliveryDocumentRequest) ([][]sales.SalesOrderItem, string) {
+	byID := make(map[string]sales.SalesOrderItem, len(items))
+	for _, item := range items {
+		byID[item.OrderItemID] = item
+	}
+
+	requested := make(map[string]float64)
+	var chunks [][]sales.SalesOrderItem
+
+	for i, document := range documents {
+		if len(document.Items) == 0 {
+			return nil, fmt.Sprintf("Delivery document %d has no items.", i+1)
+		}
+
+		seen := make(map[string]bool, len(document.Items))
+		var chunk []sales.SalesOrderItem
+		for _, line := range document.Items {
+			item, ok := byID[line.OrderItemID]
+			if !ok {
+				return nil, fmt.Sprintf("The indicated order_item_id does not exist (%s).", line.OrderItemID)
+			}
+			if seen[line.OrderItemID] {
+				return nil, fmt.Sprintf("Order item %s is listed twice on delivery document %d.", line.OrderItemID, i+1)
+			}
+			seen[line.OrderItemID] = true
+
+			if line.Quantity <= 0 {
+				return nil, fmt.Sprintf("The quantity of order item %s must be greater than 0.", line.OrderItemID)
+			}
+			requested[line.OrderItemID] += line.Quantity
+			if requested[line.OrderItemID] > item.OpenQuantity()+sales.QuantityEpsilon {
+				return nil, fmt.Sprintf("The quantity of order item %s exceeds its open quantity (%g).", line.OrderItemID, item.OpenQuantity())
+			}
+
+			item.Quantity = line.Quantity
+			item.DeliveredQuantity += requested[line.OrderItemID]
+			chunk = append(chunk, item)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, ""
+}
+
+// createDeliveryDocuments stores the planned documents, books their
+// quantities on the order items and moves the order along in one transaction
+func createDeliveryDocuments(coopId string, payload *delivery.CreateDeliveryDocumentSchema, chunks [][]sales.SalesOrderItem) error {
+	now := clock.Now().UTC()
+	expiration := now.Add(time.Duration(initializers.AppConfig.ExpirationTimeSeconds) * time.Second)
+	status := ComputeExpirationStatus(&now, initializers.AppConfig.ExpirationTimeSeconds)
 
 	ctx := context.Background()
 	q := query.Use(initializers.DB)
 
+	// Codes come from their own counters, so they are drawn before the transaction
+	var rows []delivery.CreateDeliveryDocuments
 	for _, document := range chunks {
-		deliveryDocCode, err := GenerateNextDeliveryDocumentCode(ctx, q)
-		deliverydocumentId := GenerateNextOrderItemTempID()
+		deliveryDocCode, err := GenerateNextDeliveryDocumentCode(ctx, q, coopId)
 		if err != nil {
 			return err
 		}
+		deliverydocumentId := GenerateNextOrderItemTempID()
 
 		for _, item := range document {
-			stockKeepingUnit := generate9DigitID()
-			// expirationTime := now.Add(time.Duration(initializers.AppConfig.ExpirationTimeHour) * time.Hour)
-			expiration := time.Now().Add(
-				time.Duration(initializers.AppConfig.ExpirationTimeSeconds) * time.Second)
-			status := ComputeExpirationStatus(&now, initializers.AppConfig.ExpirationTimeSeconds)
-			deliveryItem := delivery.CreateDeliveryDocuments{
+			rows = append(rows, delivery.CreateDeliveryDocuments{
 				CoopID:            coopId,
 				ErpSalesOrderCode: payload.ErpSalesOrderCode,
 				OrderID:           payload.OrderID,
@@ -254,43 +208,117 @@ func CreateCustomerDeliveryDocumentDetailsHandler(c *fiber.Ctx) error {
 				DeliveryDocumentCode: deliveryDocCode,
 
 				OrderItemID:      item.OrderItemID,
-				StockKeppingUnit: stockKeepingUnit,
+				StockKeppingUnit: generate9DigitID(),
+				Quantity:         item.Quantity,
 				CreatedAt:        &now,
 				UpdatedAt:        &now,
 				IdCreatedAt:      &now,
 				ExpirationTime:   &expiration,
 				Status:           status,
-			}
+			})
+		}
+	}
 
-			if err := initializers.DB.Create(&deliveryItem).Error; err != nil {
-				return err
+	return initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Waits for an amendment replacing the items to finish
+		var order sales.SalesOrder
+		if err := lockSalesOrder(tx, coopId, payload.OrderID, &order); err != nil {
+			return err
+		}
+
+		for _, document := range chunks {
+			for _, item := range document {
+				// Only book the quantity if it is still open
+				res := tx.Model(&sales.SalesOrderItem{}).
+					Where("id = ? AND quantity - delivered_quantity >= ?", item.ID, item.Quantity-sales.QuantityEpsilon).
+					UpdateColumn("delivered_quantity", gorm.Expr("delivered_quantity + ?", item.Quantity))
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected == 0 {
+					return errDeliveryQuantityConflict
+				}
 			}
 		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return err
+		}
+		return syncSalesOrderDeliveryStatus(tx, coopId, payload.OrderID, "Delivery documents created")
+	})
+}
+
+// CreateCustomerDeliveryDocumentDetailsHandler handles POST /spic_to_erp/customers/:coopId/salesorders/deliverydocuments
+// @Summary      Create deliverydocuments details for a sales order
+// @Description  Splits the open order items into no_of_delivery_documents documents, or delivers the per-item quantities of each entry of delivery_documents. The order must be CONFIRMED or PARTIALLY_DELIVERED; further documents can be created until it is DELIVERED.
+// @Tags         deliverydocuments
+// @Accept       json
+// @Produce      json
+// @Param        coopId path      string  true   " "
+// @Param        detail  body      delivery.CreateDeliveryDocumentSchema    true  "Create delivery document Payload"
+// @Success      200    {object}  delivery.CreateDeliveryDocumentSuccessResponse
+// @Router       /spic_to_erp/customers/{coopId}/salesorders/deliverydocuments [post]
+func CreateCustomerDeliveryDocumentDetailsHandler(c *fiber.Ctx) error {
+	coopId := c.Params("coopId")
+
+	var payload *delivery.CreateDeliveryDocumentSchema
+	var salesOrder sales.SalesOrder
+
+	var salesOrderItemsList []sales.SalesOrderItem
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
 	}
 
-	// for docIndex, document := range chunks { // Each delivery doc
-	// 	for _, item := range document { // Each item inside doc
-	// 		// Save in DB
-	// 		deliveryItem := delivery.CreateDeliveryDocuments{
-	// 			CoopID:               coopId,
-	// 			ErpSalesOrderCode:    payload.ErpSalesOrderCode,
-	// 			OrderID:              payload.OrderID,
-	// 			DeliveryDocumentID:   strconv.Itoa(docIndex + 1),
-	// 			DeliveryDocumentCode: "",
-	// 			OrderItemID:          item.OrderItemID,
-	// 			CreatedAt:            &now,
-	// 			UpdatedAt:            &now,
-	// 		}
-	// 		initializers.DB.Create(&deliveryItem)
-	// 	}
-	// }
+	salesErr := initializers.DB.Where("order_id = ? AND erp_sales_order_code = ?", payload.OrderID, payload.ErpSalesOrderCode).First(&salesOrder).Error
+	if salesErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"Message": "OrderId or SalesOrder not found "})
+	}
+
+	if salesOrder.Status == sales.StatusCancelled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"Message": "The sales order has been cancelled"})
+	}
+
+	if salesOrder.Status != sales.StatusConfirmed && salesOrder.Status != sales.StatusPartiallyDelivered {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"Message": "Delivery documents cannot be created for a sales order in status " + salesOrder.Status,
+		})
+	}
+
+	orderItemserr := initializers.DB.Where("order_id = ?", payload.OrderID).Order("id").Find(&salesOrderItemsList).Error
+	if orderItemserr != nil || len(salesOrderItemsList) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"Message": "No items found"})
+	}
+
+	if sales.FullyDelivered(salesOrderItemsList) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"Message": "The sales order has already been fully delivered"})
+	}
+
+	var chunks [][]sales.SalesOrderItem
+	var msg string
+	if len(payload.DeliveryDocuments) > 0 {
+		chunks, msg = planDeliveryQuantities(salesOrderItemsList, payload.DeliveryDocuments)
+	} else {
+		chunks, msg = planDeliveryChunks(salesOrderItemsList, payload.NoofDeliveryDocuments)
+	}
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"Message": msg})
+	}
+
+	if err := createDeliveryDocuments(coopId, payload, chunks); err != nil {
+		if errors.Is(err, errDeliveryQuantityConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"Message": "The open quantity of the order changed, please retry",
+			})
+		}
+		return sendSalesOrderStatusConflict(c, err)
+	}
 
 	// Response
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"deliveryDocuments": chunks,
 	})
-
-	// return c.Status(fiber.StatusCreated).JSON(salesOrderItemsList)
 }
 
 // GetCustomerDeliveryDocumentDetailHandler handles GET /spic_to_erp/customers/:coopId/salesorders/deliverydocuments
@@ -302,6 +330,7 @@ func CreateCustomerDeliveryDocumentDetailsHandler(c *fiber.Ctx) error {
 // @Param        coopId path      string  true   " "
 // @Param        updatedFrom   query     string  false  " "
 // @Param        updatedTo     query     string  false  " "
+// @Param        status        query     string  false  "EXPIRED, NOT EXPIRED or VOIDED"
 // @Param        page          query     int     false  "Page number"    default(1)
 // @Param        perPage         query     int     false  "Items per page" default(10)
 // @Success      200    {object}  delivery.ListDeliveryDocumentsResponse
@@ -318,6 +347,13 @@ func GetCustomerDeliveryDocumentDetailHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	status, ok := parseDeliveryStatusFilter(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid status. Use EXPIRED, NOT EXPIRED or VOIDED",
+		})
+	}
+
 	type SalesWithDelivery struct {
 		TempID            string `gorm:"column:temp_id"`
 		ErpSalesOrderId   string `gorm:"column:erp_sales_order_id"`
@@ -357,6 +393,9 @@ func GetCustomerDeliveryDocumentDetailHandler(c *fiber.Ctx) error {
 		}
 		query = query.Where("delivery_documents.updated_at >= ? AND delivery_documents.updated_at <= ?", fromTime, toTime)
 	}
+	if status != "" {
+		query = query.Where("delivery_documents.status = ?", status)
+	}
 
 	query.Select("COUNT(DISTINCT sales_orders.order_id)").Count(&totalRecords)
 
@@ -405,6 +444,7 @@ func GetCustomerDeliveryDocumentDetailHandler(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        coopId path      string  true   " "
 // @Param        orderId path      string  true   " "
+// @Param        status  query     string  false  "EXPIRED, NOT EXPIRED or VOIDED"
 // @Success      200    {object}  delivery.DeliveryNotesResponse
 // @Router       /spic_to_erp/customers/{coopId}/salesorders/{orderId}/deliverydocuments [get]
 func GetDeliveryDetailParticularHandler(c *fiber.Ctx) error {
@@ -423,6 +463,12 @@ func GetDeliveryDetailParticularHandler(c *fiber.Ctx) error {
 			"Message": "The indicated cooperative does not exist.",
 		})
 	}
+	status, ok := parseDeliveryStatusFilter(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Message": "Invalid status. Use EXPIRED, NOT EXPIRED or VOIDED",
+		})
+	}
 	var order sales.SalesOrder
 	if err := initializers.DB.
 		Where("order_id = ? AND coop_id = ?", orderID, coopId).
@@ -435,6 +481,7 @@ func GetDeliveryDetailParticularHandler(c *fiber.Ctx) error {
 	var orderItems []sales.SalesOrderItem
 	if err := initializers.DB.
 		Where("order_id = ?", orderID).
+		Order("id").
 		Find(&orderItems).Error; err != nil {
 
 		return c.Status(500).JSON(fiber.Map{
@@ -444,8 +491,12 @@ func GetDeliveryDetailParticularHandler(c *fiber.Ctx) error {
 	}
 
 	var deliveryDocs []delivery.CreateDeliveryDocuments
-	if err := initializers.DB.
-		Where("order_id = ? AND coop_id = ?", orderID, coopId).
+	docQuery := initializers.DB.Where("order_id = ? AND coop_id = ?", orderID, coopId)
+	if status != "" {
+		docQuery = docQuery.Where("status = ?", status)
+	}
+	if err := docQuery.
+		Order("id").
 		Find(&deliveryDocs).Error; err != nil {
 
 		return c.Status(500).JSON(fiber.Map{
@@ -454,19 +505,42 @@ func GetDeliveryDetailParticularHandler(c *fiber.Ctx) error {
 		})
 	}
 	deliveryMap := make(map[string][]delivery.CreateDeliveryDocuments)
+	var docCodes []string // in creation order
 
 	for _, doc := range deliveryDocs {
+		if _, ok := deliveryMap[doc.DeliveryDocumentCode]; !ok {
+			docCodes = append(docCodes, doc.DeliveryDocumentCode)
+		}
 		deliveryMap[doc.DeliveryDocumentCode] =
 			append(deliveryMap[doc.DeliveryDocumentCode], doc)
 	}
-	var response delivery.DeliveryNotesResponse
+	response := delivery.DeliveryNotesResponse{
+		OrderItems:     make([]delivery.DeliveryOrderItem, 0, len(orderItems)),
+		FullyDelivered: sales.FullyDelivered(orderItems),
+	}
+	for _, item := range orderItems {
+		response.OrderItems = append(response.OrderItems, delivery.DeliveryOrderItem{
+			OrderItemID:       item.OrderItemID,
+			ERPItemID:         item.ErpItemID,
+			Quantity:          item.Quantity,
+			DeliveredQuantity: item.DeliveredQuantity,
+			OpenQuantity:      item.OpenQuantity(),
+		})
+	}
 
-	for docCode, docs := range deliveryMap {
+	for _, docCode := range docCodes {
+		docs := deliveryMap[docCode]
 
 		note := delivery.DeliveryNote{
 			ERPDeliveryDocumentId:   docs[0].DeliveryDocumentID,
 			ERPDeliveryDocumentCode: docCode,
 			ERPDeliveryDocumentDate: *docs[0].CreatedAt,
+			Status:                  docs[0].Status,
+
+			PreviousERPDeliveryDocumentId:     docs[0].PreviousDeliveryDocumentID,
+			PreviousERPDeliveryDocumentCode:   docs[0].PreviousDeliveryDocumentCode,
+			ReplacedByERPDeliveryDocumentId:   docs[0].ReplacedByDeliveryDocumentID,
+			ReplacedByERPDeliveryDocumentCode: docs[0].ReplacedByDeliveryDocumentCode,
 		}
 
 		for _, d := range docs {
@@ -476,7 +550,8 @@ func GetDeliveryDetailParticularHandler(c *fiber.Ctx) error {
 					note.Items = append(note.Items, delivery.DeliveryItem{
 						ERPItemID2:       item.ErpItemID2,
 						StockKeepingUnit: d.StockKeppingUnit,
-						Quantity:         item.Quantity,
+						Quantity:         d.Quantity,
+						OpenQuantity:     item.OpenQuantity(),
 						SalesOrder: delivery.DeliverySalesOrder{
 							TempERPSalesOrderId: order.TempID,
 							ERPSalesOrderId:     order.ErpSalesOrderId,
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shyamsundaar/karino-mock-server/models/delivery"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+)
+
+func deliveryTestItems() []sales.SalesOrderItem {
+	return []sales.SalesOrderItem{
+		{OrderItemID: "I1", Quantity: 10},
+		{OrderItemID: "I2", Quantity: 5, DeliveredQuantity: 2},
+		{OrderItemID: "I3", Quantity: 4, DeliveredQuantity: 4},
+	}
+}
+
+func deliveryRequest(lines ...[]delivery.DeliveryDocumentItemRequest) []delivery.DeliveryDocumentRequest {
+	documents := make([]delivery.DeliveryDocumentRequest, 0, len(lines))
+	for _, items := range lines {
+		documents = append(documents, delivery.DeliveryDocumentRequest{Items: items})
+	}
+	return documents
+}
+
+func line(orderItemID string, quantity float64) delivery.DeliveryDocumentItemRequest {
+	return delivery.DeliveryDocumentItemRequest{OrderItemID: orderItemID, Quantity: quantity}
+}
+
+func TestPlanDeliveryQuantities(t *testing.T) {
+	type planned struct {
+		id                  string
+		quantity, delivered float64
+	}
+
+	tests := []struct {
+		name      string
+		documents []delivery.DeliveryDocumentRequest
+		want      [][]planned
+		wantMsg   string
+	}{
+		{
+			name:      "one document",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I1", 4), line("I2", 3)}),
+			want:      [][]planned{{{"I1", 4, 4}, {"I2", 3, 5}}},
+		},
+		{
+			name:      "an item split across documents",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I1", 4)}, []delivery.DeliveryDocumentItemRequest{line("I1", 2.5), line("I2", 1)}),
+			want:      [][]planned{{{"I1", 4, 4}}, {{"I1", 2.5, 6.5}, {"I2", 1, 3}}},
+		},
+		{
+			name:      "the whole open quantity",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I1", 10), line("I2", 3)}),
+			want:      [][]planned{{{"I1", 10, 10}, {"I2", 3, 5}}},
+		},
+		{
+			name:      "more than open",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I2", 3.5)}),
+			wantMsg:   "exceeds its open quantity",
+		},
+		{
+			name:      "more than open across documents",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I1", 6)}, []delivery.DeliveryDocumentItemRequest{line("I1", 5)}),
+			wantMsg:   "exceeds its open quantity",
+		},
+		{
+			name:      "fully delivered item",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I3", 1)}),
+			wantMsg:   "exceeds its open quantity",
+		},
+		{
+			name:      "zero quantity",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I1", 0)}),
+			wantMsg:   "must be greater than 0",
+		},
+		{
+			name:      "unknown item",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I9", 1)}),
+			wantMsg:   "does not exist",
+		},
+		{
+			name:      "item twice on a document",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I1", 1), line("I1", 1)}),
+			wantMsg:   "listed twice",
+		},
+		{
+			name:      "empty document",
+			documents: deliveryRequest([]delivery.DeliveryDocumentItemRequest{line("I1", 1)}, nil),
+			wantMsg:   "has no items",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, msg := planDeliveryQuantities(deliveryTestItems(), tt.documents)
+			if tt.wantMsg != "" {
+				if !strings.Contains(msg, tt.wantMsg) {
+					t.Fatalf("message = %q, want it to contain %q", msg, tt.wantMsg)
+				}
+				return
+			}
+			if msg != "" {
+				t.Fatalf("unexpected message %q", msg)
+			}
+
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("got %d documents, want %d", len(chunks), len(tt.want))
+			}
+			for i, chunk := range chunks {
+				if len(chunk) != len(tt.want[i]) {
+					t.Fatalf("document %d has %d lines, want %d", i+1, len(chunk), len(tt.want[i]))
+				}
+				for j, item := range chunk {
+					want := tt.want[i][j]
+					if item.OrderItemID != want.id || item.Quantity != want.quantity || item.DeliveredQuantity != want.delivered {
+						t.Errorf("document %d line %d = %s %g (delivered %g), want %s %g (delivered %g)",
+							i+1, j+1, item.OrderItemID, item.Quantity, item.DeliveredQuantity, want.id, want.quantity, want.delivered)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPlanDeliveryChunks(t *testing.T) {
+	tests := []struct {
+		n       int
+		sizes   []int
+		wantMsg string
+	}{
+		{n: 1, sizes: []int{2}},
+		{n: 2, sizes: []int{1, 1}},
+		{n: 3, wantMsg: "cannot be greater"},
+		{n: 0, wantMsg: "must be greater than 0"},
+	}
+
+	for _, tt := range tests {
+		chunks, msg := planDeliveryChunks(deliveryTestItems(), tt.n)
+		if tt.wantMsg != "" {
+			if !strings.Contains(msg, tt.wantMsg) {
+				t.Errorf("n=%d: message = %q, want it to contain %q", tt.n, msg, tt.wantMsg)
+			}
+			continue
+		}
+		if len(chunks) != len(tt.sizes) {
+			t.Fatalf("n=%d: got %d documents, want %d", tt.n, len(chunks), len(tt.sizes))
+		}
+		for i, chunk := range chunks {
+			if len(chunk) != tt.sizes[i] {
+				t.Errorf("n=%d: document %d has %d lines, want %d", tt.n, i+1, len(chunk), tt.sizes[i])
+			}
+			// Each line delivers the item's whole open quantity
+			for _, item := range chunk {
+				if item.OrderItemID == "I2" && item.Quantity != 3 {
+					t.Errorf("n=%d: I2 delivers %g, want its open 3", tt.n, item.Quantity)
+				}
+			}
+		}
+	}
+}
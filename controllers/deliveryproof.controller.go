@@ -1,15 +1,20 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"mime/multipart"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/shyamsundaar/karino-mock-server/initializers"
 
-	// "github.com/shyamsundaar/karino-mock-server/models/delivery"
+	"github.com/shyamsundaar/karino-mock-server/models/delivery"
 	"github.com/shyamsundaar/karino-mock-server/models/deliveryproof"
+	"github.com/shyamsundaar/karino-mock-server/models/invoices"
+	"github.com/shyamsundaar/karino-mock-server/models/jobs"
+	"gorm.io/gorm"
 
 	// "context"
 	"strconv"
@@ -24,9 +29,9 @@ func GenerateAndSetNextERPproofIDGen() string {
 
 // CreateDeliveryDocumentsProofHandler handles POST /spic_to_erp/customers/:coopId/deliverydocuments/:deliveryNoteId/proof
 // @Summary      Create deliverydocuments proof for a sales order
-// @Description  Create deliverydocuments proof for a sales order
+// @Description  Create deliverydocuments proof for a sales order. Photos can be uploaded by sending multipart/form-data with the JSON payload in a "proof" field and any number of "photos" files.
 // @Tags         deliverydocuments proof
-// @Accept       json
+// @Accept       json,mpfd
 // @Produce      json
 // @Param        coopId path      string  true   " "
 // @Param        deliveryNoteId path      string  true   " "
@@ -37,18 +42,72 @@ func CreateDeliveryDocumentsProofHandler(c *fiber.Ctx) error {
 	var payload deliveryproof.CreateDeliveryDocumentProofSchema
 	coopId := c.Params("coopId")
 
-	if err := c.BodyParser(&payload); err != nil {
+	// multipart/form-data carries the JSON payload in the "proof" field
+	// next to the uploaded "photos"
+	var form *multipart.Form
+	if isMultipart(c) {
+		var err error
+		if form, err = c.MultipartForm(); err == nil {
+			err = json.Unmarshal([]byte(c.FormValue(proofFormField)), &payload)
+		}
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+	} else if err := c.BodyParser(&payload); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
 	}
+	// The route names the delivery document being proved
+	if deliveryNoteId := c.Params("deliveryNoteId"); deliveryNoteId != "" {
+		payload.Waybill.DeliveryNoteID = deliveryNoteId
+	}
 	
 
 	if !isCoopAllowed(coopId) {
 		return SendDocumentdeliveryProofErrorResponse(c, "The indicated cooperative does not exist.")
 	}
 
+	var voided int64
+	initializers.DB.Model(&delivery.CreateDeliveryDocuments{}).
+		Where("coop_id = ? AND delivery_document_id = ? AND status = ?", coopId, payload.Waybill.DeliveryNoteID, StatusVoided).
+		Count(&voided)
+	if voided > 0 {
+		return SendDocumentdeliveryProofErrorResponse(c, "The indicated delivery document has been voided.")
+	}
+
+	// An order has a proof per delivery document
+	var proofs int64
+	initializers.DB.Model(&deliveryproof.Waybill{}).
+		Where("coop_id = ? AND delivery_note_id = ?", coopId, payload.Waybill.DeliveryNoteID).
+		Count(&proofs)
+	if proofs > 0 {
+		return SendDocumentdeliveryProofErrorResponse(c, "A delivery proof already exists for the indicated delivery document.")
+	}
+
+	deliveryPhotos := linkedPhotos(payload.Waybill.DeliveryPhotoProofURL1, payload.Waybill.DeliveryPhotoProofURL2)
+	var pendingPhotos []pendingPhoto
+	if form != nil {
+		var msg string
+		var err error
+		pendingPhotos, msg, err = checkUploadedPhotos(form, coopId, payload.Waybill.DeliveryNoteID, len(deliveryPhotos))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to read delivery photos",
+				"reason":  err.Error(),
+			})
+		}
+		if msg != "" {
+			return SendDocumentdeliveryProofErrorResponse(c, msg)
+		}
+		deliveryPhotos = append(deliveryPhotos, pendingPhotoList(pendingPhotos)...)
+	}
+
 	// ------------------------------------------
 	// 1️⃣ MAP: WaybillProof → Waybill (DB Model)
 	// ------------------------------------------
@@ -68,56 +127,69 @@ func CreateDeliveryDocumentsProofHandler(c *fiber.Ctx) error {
 		DeliveryNoteID:       payload.Waybill.DeliveryNoteID,
 		DeliveryNoteDocument: payload.Waybill.DeliveryNoteDocument,
 
-		// Delivery photos → JSON string
-		DeliveryPhotos: fmt.Sprintf(`[
-            {"url1":"%s","url2":"%s"}
-        ]`, payload.Waybill.DeliveryPhotoProofURL1, payload.Waybill.DeliveryPhotoProofURL2),
+		DeliveryPhotos: deliveryPhotos,
 	}
 
-	// Insert waybill
-	if err := initializers.DB.Create(&newWaybill).Error; err != nil {
+	// ------------------------------------------
+	// 2️⃣ STORE: waybill, items, invoice and photos in one transaction
+	// ------------------------------------------
+	var invoiceJob *jobs.Job
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newWaybill).Error; err != nil {
+			return fmt.Errorf("failed to insert waybill: %w", err)
+		}
+
+		var items []deliveryproof.WaybillItem
+		for _, item := range payload.WaybillItems {
+			items = append(items, deliveryproof.WaybillItem{
+				WaybillID:        newWaybill.ID,
+				OrderID:          payload.Waybill.OrderID,
+				Name:             item.Name,
+				NumberOfUnits:    item.NumberOfUnits,
+				Quantity:         item.Quantity,
+				QuantityUnitKey:  item.QuantityUnitKey,
+				UnitPrice:        item.UnitPrice,
+				ErpItemID:			  GenerateAndSetNextERPproofIDGen(),
+				ErpItemID2:			  GenerateAndSetNextERPproofIDGen(),
+				Price:            item.Price,
+				PriceUnitKey:     item.PriceUnitKey,
+				Status:           item.Status,
+				StockKeepingUnit: item.StockKeepingUnit,
+			})
+		}
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return fmt.Errorf("failed to insert waybill items: %w", err)
+			}
+		}
+
+		// Invoice the delivered items
+		var err error
+		if invoiceJob, err = createWaybillInvoice(tx, &newWaybill, items); err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+
+		// Photos go to the blob store last, once nothing else can fail
+		if err := storePendingPhotos(pendingPhotos); err != nil {
+			return fmt.Errorf("failed to store delivery photos: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
-			"error":   "Failed to insert waybill",
+			"error":   "Failed to store delivery proof",
 			"reason":  err.Error(),
 		})
 	}
 
 	// ------------------------------------------
-	// 2️⃣ MAP: WaybillItemProof → WaybillItem
+	// 3️⃣ ISSUE THE INVOICE (after commit)
 	// ------------------------------------------
-	var items []deliveryproof.WaybillItem
-
-	for _, item := range payload.WaybillItems {
-		items = append(items, deliveryproof.WaybillItem{
-			OrderID:          payload.Waybill.OrderID, // FK match
-			Name:             item.Name,
-			NumberOfUnits:    item.NumberOfUnits,
-			Quantity:         item.Quantity,
-			QuantityUnitKey:  item.QuantityUnitKey,
-			UnitPrice:        item.UnitPrice,
-			ErpItemID:			  GenerateAndSetNextERPproofIDGen(),
-			ErpItemID2:			  GenerateAndSetNextERPproofIDGen(),
-			Price:            item.Price,
-			PriceUnitKey:     item.PriceUnitKey,
-			Status:           item.Status,
-			StockKeepingUnit: item.StockKeepingUnit,
-		})
-	}
-
-	// Insert items
-	if len(items) > 0 {
-		if err := initializers.DB.Create(&items).Error; err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"success": false,
-				"error":   "Failed to insert waybill items",
-				"reason":  err.Error(),
-			})
-		}
-	}
+	issueWaybillInvoice(invoiceJob)
 
 	// ------------------------------------------
-	// 3️⃣ RETURN RESPONSE
+	// 4️⃣ RETURN RESPONSE
 	// ------------------------------------------
 	return c.Status(201).JSON(deliveryproof.CreateDocumentdeliveryProofSuccessResponse{
 		Success: true,
@@ -125,6 +197,7 @@ func CreateDeliveryDocumentsProofHandler(c *fiber.Ctx) error {
 			TempERPProofId: newWaybill.TempID, // return primary key
 			OrderId: newWaybill.OrderID,
 			Message: "Delivery proof created successfully",
+			Photos:  deliveryPhotos,
 		},
 	})
 }
@@ -141,8 +214,8 @@ func SendDocumentdeliveryProofErrorResponse(c *fiber.Ctx, message string) error
 }
 
 // GetDeliveryDocumentsProofHandler handles GET /spic_to_erp/customers/:coopId/deliverydocuments/invoices
-// @Summary      Create deliverydocuments proof for a sales order within date range
-// @Description  Create deliverydocuments proof for a sales order within date range
+// @Summary      List the invoices issued for delivery proofs within date range
+// @Description  List the invoices issued for delivery proofs within date range
 // @Tags         deliverydocuments proof
 // @Accept       json
 // @Produce      json
@@ -157,7 +230,7 @@ func GetDeliveryDocumentsProofHandler(c *fiber.Ctx) error {
 	coopId := c.Params("coopId")
 	updatedFrom := c.Query("updatedFrom")
 	updatedTo := c.Query("updatedTo")
-	var deliverydocuments []deliveryproof.DocumentdeliveryProof
+	var issued []invoices.Invoice
 	emptydata := make([]deliveryproof.ListDeliveryDocumentsResponse, 0)
 
 	if !isCoopAllowed(coopId) {
@@ -167,6 +240,9 @@ func GetDeliveryDocumentsProofHandler(c *fiber.Ctx) error {
 	}
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	perPage, _ := strconv.Atoi(c.Query("perPage", "10"))
+	if page <= 0 {
+		page = 1
+	}
 	if perPage <= 0 {
 		perPage = 10
 	}
@@ -174,9 +250,10 @@ func GetDeliveryDocumentsProofHandler(c *fiber.Ctx) error {
 	offset := (page - 1) * perPage
 	var totalRecords int64
 
+	// Pending invoices have no ERP ID or code yet
 	query := initializers.DB.
-		Model(&deliveryproof.WaybillItem{}).
-		Where("coop_id = ?", coopId)
+		Model(&invoices.Invoice{}).
+		Where("coop_id = ? AND status = ?", coopId, invoices.StatusIssued)
 
 	if updatedFrom != "" && updatedTo != "" {
 		fromTime, err := time.Parse(time.RFC3339, updatedFrom)
@@ -199,9 +276,10 @@ func GetDeliveryDocumentsProofHandler(c *fiber.Ctx) error {
 	query.Count(&totalRecords)
 
 	if err := query.
+		Order("id").
 		Limit(perPage).
 		Offset(offset).
-		Find(&deliverydocuments).Error; err != nil {
+		Find(&issued).Error; err != nil {
 
 		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
 			"Invoice": emptydata,
@@ -211,10 +289,13 @@ func GetDeliveryDocumentsProofHandler(c *fiber.Ctx) error {
 	totalPages := int(math.Ceil(float64(totalRecords) / float64(perPage)))
 
 	data := make([]deliveryproof.DocumentdeliveryProof, 0)
-	for _, f := range deliverydocuments {
+	for _, inv := range issued {
 		data = append(data, deliveryproof.DocumentdeliveryProof{
-			ERPDeliveryDocumentId:   f.ERPDeliveryDocumentId,
-			ERPDeliveryDocumentCode: f.ERPDeliveryDocumentCode,
+			ERPDeliveryDocumentId:   inv.DeliveryDocumentID,
+			ERPDeliveryDocumentCode: inv.DeliveryDocumentCode,
+			ERPInvoiceId:            inv.ErpInvoiceID,
+			ERPInvoiceCode:          inv.ErpInvoiceCode,
+			ERPInvoiceDate:          formatInvoiceDate(inv.InvoiceDate),
 		})
 	}
 
@@ -229,103 +310,75 @@ func GetDeliveryDocumentsProofHandler(c *fiber.Ctx) error {
 			HasNext:     page < totalPages,
 		},
 	})
-	// return c.Status(fiber.StatusCreated).JSON(response)
 }
 
-// GetDeliveryDocumentsProofParticularHandler handles GET /spic_to_erp/customers/:coopId/deliverydocuments/:deliveryNoteId/proof
-// @Summary      Create deliverydocuments proof for a sales order
-// @Description  Create deliverydocuments proof for a sales order
+// GetDeliveryDocumentsProofParticularHandler handles GET /spic_to_erp/customers/:coopId/deliverydocuments/:deliveryNoteId/invoices
+// @Summary      Get the invoices issued for a delivery document
+// @Description  Get the invoices issued for a delivery document
 // @Tags         deliverydocuments proof
 // @Accept       json
 // @Produce      json
 // @Param        coopId path      string  true   " "
 // @Param        deliveryNoteId path      string  true   " "
 // @Success      200    {object}  deliveryproof.InvoicesResponse
-// @Router       /spic_to_erp/customers/{coopId}/deliverydocuments/{deliveryNoteId}/proof [get]
+// @Router       /spic_to_erp/customers/{coopId}/deliverydocuments/{deliveryNoteId}/invoices [get]
 func GetDeliveryDocumentsProofParticularHandler(c *fiber.Ctx) error {
 	coopId := c.Params("coopId")
-	orderID := c.Params("orderId")
-	emptydata := make([]deliveryproof.InvoicesResponse, 0)
-
-	// if orderID == "" {
-	//  return c.Status(400).JSON(fiber.Map{
-	//      "success": false,
-	//      "message": "order_id is required",
-	//  })
-	// }
+	deliveryNoteId := c.Params("deliveryNoteId")
+
 	if !isCoopAllowed(coopId) {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"Message": "The indicated cooperative does not exist.",
 		})
 	}
-	var order deliveryproof.Waybill
-	if err := initializers.DB.
-		Where("order_id = ? AND coop_id = ?", orderID, coopId).
-		First(&order).Error; err != nil {
 
-		return c.Status(200).JSON(fiber.Map{
-			"deliverynotes": emptydata,
-		})
-	}
-	var orderItems []deliveryproof.WaybillItem
-	if err := initializers.DB.
-		Where("order_id = ?", orderID).
-		Find(&orderItems).Error; err != nil {
-
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to fetch order items",
-		})
-	}
-	var deliveryDocs []deliveryproof.Waybill
+	var issued []invoices.Invoice
 	if err := initializers.DB.
-		Where("order_id = ? AND coop_id = ?", orderID, coopId).
-		Find(&deliveryDocs).Error; err != nil {
+		Preload("Lines").
+		Where("coop_id = ? AND delivery_document_id = ? AND status = ?", coopId, deliveryNoteId, invoices.StatusIssued).
+		Order("id").
+		Find(&issued).Error; err != nil {
 
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
-			"message": "Failed to fetch delivery documents",
+			"message": "Failed to fetch invoices",
 		})
 	}
-	deliveryMap := make(map[string][]deliveryproof.Waybill)
-
-	for _, doc := range deliveryDocs {
-		deliveryMap[doc.DeliveryNoteID] =
-			append(deliveryMap[doc.DeliveryNoteID], doc)
-	}
-	var response deliveryproof.InvoicesResponse
 
-	for docCode, docs := range deliveryMap {
-
-		note := deliveryproof.Invoice{
-			ERPInvoiceId:   docs[0].DeliveryNoteID,
-			ERPInvoiceCode: docCode,
-			// ERPInvoiceDate: *docs[0].CreatedAt,
+	response := deliveryproof.InvoicesResponse{Invoices: make([]deliveryproof.Invoice, 0, len(issued))}
+	for _, inv := range issued {
+		invoice := deliveryproof.Invoice{
+			ERPInvoiceId:   inv.ErpInvoiceID,
+			ERPInvoiceCode: inv.ErpInvoiceCode,
+			ERPInvoiceDate: formatInvoiceDate(inv.InvoiceDate),
+			Currency:       inv.Currency,
+			NetAmount:      inv.NetAmount,
+			TaxAmount:      inv.TaxAmount,
+			TotalAmount:    inv.TotalAmount,
+			Items:          make([]deliveryproof.InvoiceItem, 0, len(inv.Lines)),
 		}
 
-		for _, d := range docs {
-			for _, item := range orderItems {
-				if item.OrderID == d.OrderID {
-
-					note.Items = append(note.Items, deliveryproof.InvoiceItem{
-						// ERPItemID:       item.ErpItemID2,
-						StockKeepingUnit: item.StockKeepingUnit,
-						Quantity:         item.Quantity,
-						DeliveryNote: deliveryproof.InvoiceDeliveryNote{
-							// TempERPDeliveryNoteId: order.TempID,
-							ERPDeliveryDocumentId:   order.DeliveryNoteID,
-							ERPDeliveryDocumentCode: order.DeliveryNoteDocument,
-							ERPDeliveryDocumentDate: order.OrderID,
-							Quantity:                item.Quantity,
-							// ERPItemID:           item.ErpItemID,
-							// OrderItemID:         item.OrderItemID,
-						},
-					})
-				}
-			}
+		for _, line := range inv.Lines {
+			invoice.Items = append(invoice.Items, deliveryproof.InvoiceItem{
+				ERPItemID:        line.ErpItemID,
+				StockKeepingUnit: line.StockKeepingUnit,
+				Quantity:         line.Quantity,
+				UnitPrice:        line.UnitPrice,
+				TaxRate:          line.TaxRate,
+				NetAmount:        line.NetAmount,
+				TaxAmount:        line.TaxAmount,
+				DeliveryNote: deliveryproof.InvoiceDeliveryNote{
+					ERPDeliveryDocumentId:   inv.DeliveryDocumentID,
+					ERPDeliveryDocumentCode: inv.DeliveryDocumentCode,
+					ERPDeliveryDocumentDate: formatInvoiceDate(inv.DeliveryDocumentDate),
+					ERPItemID:               line.DeliveryNoteItemID,
+					Quantity:                line.Quantity,
+					OrderItemID:             line.OrderItemID,
+				},
+			})
 		}
 
-		response.Invoices = append(response.Invoices, note)
+		response.Invoices = append(response.Invoices, invoice)
 	}
 	return c.Status(200).JSON(response)
 }
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/delivery"
+	"github.com/shyamsundaar/karino-mock-server/models/deliveryproof"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"github.com/shyamsundaar/karino-mock-server/query"
+	"gorm.io/gorm"
+)
+
+// errDeliveryDocumentChanged means the document was voided or reissued by
+// a concurrent request
+var errDeliveryDocumentChanged = errors.New("delivery document changed")
+
+// findExpiredDeliveryDocument loads the rows of an expired delivery document
+// that has no proof yet. When the document cannot be voided it writes the
+// error response and returns false.
+func findExpiredDeliveryDocument(c *fiber.Ctx, coopId, deliveryNoteId string, rows *[]delivery.CreateDeliveryDocuments) (bool, error) {
+	if !isCoopAllowed(coopId) {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Message": "The indicated cooperative does not exist.",
+		})
+	}
+
+	var found []delivery.CreateDeliveryDocuments
+	if err := initializers.DB.
+		Where("coop_id = ? AND delivery_document_id = ?", coopId, deliveryNoteId).
+		Order("id").
+		Find(&found).Error; err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"Message": err.Error(),
+		})
+	}
+	if len(found) == 0 {
+		return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"Message": "The indicated delivery document does not exist.",
+		})
+	}
+
+	for _, row := range found {
+		if row.Status == StatusVoided {
+			return false, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"Message": "The delivery document has already been voided.",
+			})
+		}
+		if row.Status != StatusExpired {
+			return false, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"Message": "Only expired delivery documents can be voided or reissued.",
+			})
+		}
+	}
+
+	var proofs int64
+	initializers.DB.Model(&deliveryproof.Waybill{}).
+		Where("coop_id = ? AND delivery_note_id = ?", coopId, deliveryNoteId).
+		Count(&proofs)
+	if proofs > 0 {
+		return false, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"Message": "The delivery document already has a delivery proof.",
+		})
+	}
+
+	*rows = found
+	return true, nil
+}
+
+// voidDeliveryRows marks the rows of an expired document VOIDED, pointing
+// them at their replacement when there is one
+func voidDeliveryRows(tx *gorm.DB, rows []delivery.CreateDeliveryDocuments, now time.Time, replacedByID, replacedByCode string) error {
+	res := tx.Model(&delivery.CreateDeliveryDocuments{}).
+		Where("delivery_document_id = ? AND status = ?", rows[0].DeliveryDocumentID, StatusExpired).
+		Updates(map[string]interface{}{
+			"status":                             StatusVoided,
+			"voided_at":                          now,
+			"updated_at":                         now,
+			"replaced_by_delivery_document_id":   replacedByID,
+			"replaced_by_delivery_document_code": replacedByCode,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected != int64(len(rows)) {
+		return errDeliveryDocumentChanged
+	}
+	return nil
+}
+
+// sendDeliveryDocumentChanged answers a void or reissue that lost a race
+func sendDeliveryDocumentChanged(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errDeliveryDocumentChanged) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"Message": "The delivery document was changed by another request, please retry",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"Message": err.Error(),
+	})
+}
+
+// VoidDeliveryDocumentHandler handles POST /spic_to_erp/customers/:coopId/deliverydocuments/:deliveryNoteId/void
+// @Summary      Void an expired delivery document
+// @Description  Voids an expired delivery document without a proof and releases its quantities, so new delivery documents can be created for them
+// @Tags         deliverydocuments
+// @Produce      json
+// @Param        coopId path      string  true   " "
+// @Param        deliveryNoteId path      string  true   " "
+// @Success      200    {object}  delivery.VoidDeliveryDocumentResponse
+// @Router       /spic_to_erp/customers/{coopId}/deliverydocuments/{deliveryNoteId}/void [post]
+func VoidDeliveryDocumentHandler(c *fiber.Ctx) error {
+	var rows []delivery.CreateDeliveryDocuments
+	if found, err := findExpiredDeliveryDocument(c, c.Params("coopId"), c.Params("deliveryNoteId"), &rows); !found {
+		return err
+	}
+
+	now := clock.Now().UTC()
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := voidDeliveryRows(tx, rows, now, "", ""); err != nil {
+			return err
+		}
+
+		// The voided quantities are open again
+		for _, row := range rows {
+			if err := tx.Model(&sales.SalesOrderItem{}).
+				Where("order_id = ? AND order_item_id = ?", row.OrderID, row.OrderItemID).
+				UpdateColumn("delivered_quantity", gorm.Expr("delivered_quantity - ?", row.Quantity)).Error; err != nil {
+				return err
+			}
+		}
+		return syncSalesOrderDeliveryStatus(tx, rows[0].CoopID, rows[0].OrderID, "Delivery document voided")
+	})
+	if errors.Is(err, errSalesOrderStatusChanged) || errors.Is(err, sales.ErrInvalidTransition) {
+		return sendSalesOrderStatusConflict(c, err)
+	}
+	if err != nil {
+		return sendDeliveryDocumentChanged(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data": delivery.VoidDeliveryDocumentResponse{
+			ERPDeliveryDocumentId:   rows[0].DeliveryDocumentID,
+			ERPDeliveryDocumentCode: rows[0].DeliveryDocumentCode,
+			Status:                  StatusVoided,
+			VoidedAt:                now,
+		},
+	})
+}
+
+// ReissueDeliveryDocumentHandler handles POST /spic_to_erp/customers/:coopId/deliverydocuments/:deliveryNoteId/reissue
+// @Summary      Reissue an expired delivery document
+// @Description  Voids an expired delivery document without a proof and issues a new one, with a new code, for the same items and quantities
+// @Tags         deliverydocuments
+// @Produce      json
+// @Param        coopId path      string  true   " "
+// @Param        deliveryNoteId path      string  true   " "
+// @Success      201    {object}  delivery.ReissueDeliveryDocumentResponse
+// @Router       /spic_to_erp/customers/{coopId}/deliverydocuments/{deliveryNoteId}/reissue [post]
+func ReissueDeliveryDocumentHandler(c *fiber.Ctx) error {
+	coopId := c.Params("coopId")
+
+	var rows []delivery.CreateDeliveryDocuments
+	if found, err := findExpiredDeliveryDocument(c, coopId, c.Params("deliveryNoteId"), &rows); !found {
+		return err
+	}
+
+	var salesOrder sales.SalesOrder
+	if err := initializers.DB.Where("coop_id = ? AND order_id = ?", coopId, rows[0].OrderID).First(&salesOrder).Error; err == nil &&
+		salesOrder.Status == sales.StatusCancelled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"Message": "The sales order has been cancelled.",
+		})
+	}
+
+	// The code comes from its own counter, so it is drawn before the transaction
+	code, err := GenerateNextDeliveryDocumentCode(context.Background(), query.Use(initializers.DB), coopId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"Message": err.Error(),
+		})
+	}
+	id := GenerateNextOrderItemTempID()
+
+	now := clock.Now().UTC()
+	expiration := now.Add(time.Duration(initializers.AppConfig.ExpirationTimeSeconds) * time.Second)
+	status := ComputeExpirationStatus(&now, initializers.AppConfig.ExpirationTimeSeconds)
+
+	reissued := make([]delivery.CreateDeliveryDocuments, 0, len(rows))
+	for _, row := range rows {
+		reissued = append(reissued, delivery.CreateDeliveryDocuments{
+			CoopID:            row.CoopID,
+			ErpSalesOrderCode: row.ErpSalesOrderCode,
+			OrderID:           row.OrderID,
+
+			DeliveryDocumentID:   id,
+			DeliveryDocumentCode: code,
+
+			OrderItemID:      row.OrderItemID,
+			StockKeppingUnit: generate9DigitID(),
+			Quantity:         row.Quantity,
+			CreatedAt:        &now,
+			UpdatedAt:        &now,
+			IdCreatedAt:      &now,
+			ExpirationTime:   &expiration,
+			Status:           status,
+
+			PreviousDeliveryDocumentID:   row.DeliveryDocumentID,
+			PreviousDeliveryDocumentCode: row.DeliveryDocumentCode,
+		})
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := voidDeliveryRows(tx, rows, now, id, code); err != nil {
+			return err
+		}
+		return tx.Create(&reissued).Error
+	})
+	if err != nil {
+		return sendDeliveryDocumentChanged(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data": delivery.ReissueDeliveryDocumentResponse{
+			ERPDeliveryDocumentId:           id,
+			ERPDeliveryDocumentCode:         code,
+			ERPDeliveryDocumentDate:         now,
+			ExpiresAt:                       expiration,
+			Status:                          status,
+			PreviousERPDeliveryDocumentId:   rows[0].DeliveryDocumentID,
+			PreviousERPDeliveryDocumentCode: rows[0].DeliveryDocumentCode,
+		},
+	})
+}
@@ -2,38 +2,25 @@ package controllers
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"math"
-	"regexp"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/clock"
 	"github.com/shyamsundaar/karino-mock-server/initializers"
 	models "github.com/shyamsundaar/karino-mock-server/models/farmers"
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
 
 	// "karino-mock-server/query"
 	"github.com/shyamsundaar/karino-mock-server/query"
-	// "gorm.io/gorm"
+	"gorm.io/gorm"
 )
 
 func isCoopAllowed(coopId string) bool {
-	// 1. Get the string from your loaded config
-	// (Assuming initializers.Config is your global config var)
-	rawList := initializers.AppConfig.AllowedCooperatives
-
-	// 2. Split it into a slice
-	allowed := strings.Split(rawList, ",")
-
-	// 3. Check if the ID exists in the slice
-	for _, id := range allowed {
-		if strings.TrimSpace(id) == coopId {
-			return true
-		}
-	}
-	return false
+	// Known and enabled in the cooperative registry (seeded from ALLOWED_COOPERATIVES)
+	return initializers.CoopEnabled(coopId)
 }
 
 func GenerateAndSetNextCustomerIDGen(
@@ -55,39 +42,21 @@ func GenerateAndSetNextCustomerIDGen(
 		return row.CustomerID, nil
 	}
 
-	// 2. Get last non-empty customer_id
-	last, err := fd.
-		Where(q.FarmerDetails.CustomerID.Neq("")).
-		Order(q.FarmerDetails.ID.Desc()).
-		First()
-
-	// Start from C26000 → numeric = 0
-	next := 1
-
-	if err == nil && last.CustomerID != "" {
-		// Extract only the last 5 digits
-		re := regexp.MustCompile(`(\d{5})$`)
-		if m := re.FindString(last.CustomerID); m != "" {
-			n, _ := strconv.Atoi(m)
-			next = n + 1
-		}
+	// 2. Generate ID from the coop's template (default C26 + 5-digit counter)
+	newCustomerID, err := GenerateERPIdentifier(ctx, initializers.CustomerIDFormat, sequences.CustomerID, row.CoopID)
+	if err != nil {
+		return "", err
 	}
 
-	// Generate ID → C26 + 5-digit counter
-	newCustomerID := fmt.Sprintf("C26%05d", next)
-
-	// Optional business delay
-	time.Sleep(time.Duration(initializers.AppConfig.CustomerTimeSeconds) * time.Second)
-
 	// Update only if still empty (safe update)
 	_, err = fd.
 		Where(
 			q.FarmerDetails.ID.Eq(detailID),
-			q.FarmerDetails.CustomerID.Eq(""),
+			q.FarmerDetails.CustomerID.IsNull(),
 		).
 		UpdateColumnSimple(
 			q.FarmerDetails.CustomerID.Value(newCustomerID),
-			q.FarmerDetails.CustIDUpdateAt.Value(time.Now()),
+			q.FarmerDetails.CustIDUpdateAt.Value(clock.Now()),
 		)
 
 	if err != nil {
@@ -118,39 +87,21 @@ func GenerateAndSetNextVendorIDGen(
 		return row.VendorID, nil
 	}
 
-	// 2. Get last non-empty vendor_id
-	last, err := fd.
-		Where(q.FarmerDetails.VendorID.Neq("")).
-		Order(q.FarmerDetails.ID.Desc()).
-		First()
-
-	// Start counter
-	next := 1
-
-	if err == nil && last.VendorID != "" {
-		// Extract last 5 digits only
-		re := regexp.MustCompile(`(\d{5})$`)
-		if m := re.FindString(last.VendorID); m != "" {
-			n, _ := strconv.Atoi(m)
-			next = n + 1
-		}
+	// 2. Generate ID from the coop's template (default F26 + 5-digit counter)
+	newVendorID, err := GenerateERPIdentifier(ctx, initializers.VendorIDFormat, sequences.VendorID, row.CoopID)
+	if err != nil {
+		return "", err
 	}
 
-	// Generate Vendor ID → V26 + 5-digit number
-	newVendorID := fmt.Sprintf("F26%05d", next)
-
-	// Optional business delay
-	time.Sleep(time.Duration(initializers.AppConfig.VendorTimeSeconds) * time.Second)
-
 	// Update only if still empty (race-safe)
 	_, err = fd.
 		Where(
 			q.FarmerDetails.ID.Eq(detailID),
-			q.FarmerDetails.VendorID.Eq(""),
+			q.FarmerDetails.VendorID.IsNull(),
 		).
 		UpdateColumnSimple(
 			q.FarmerDetails.VendorID.Value(newVendorID),
-			q.FarmerDetails.VendorIDUpdateAt.Value(time.Now()),
+			q.FarmerDetails.VendorIDUpdateAt.Value(clock.Now()),
 		)
 
 	if err != nil {
@@ -201,14 +152,9 @@ func CreateCustomerDetailHandler(c *fiber.Ctx) error {
 
 	if err == nil {
 		if existingFarmer.CustomerID == "" {
-			ctx := context.Background()
-			q := query.Use(initializers.DB)
-
-			go func(id uint) {
-				if _, err := GenerateAndSetNextCustomerIDGen(ctx, q, id); err != nil {
-					log.Println("❌ Customer ID generation failed:", err)
-				}
-			}(existingFarmer.ID)
+			if err := enqueueCustomerIDJob(initializers.DB, coopId, existingFarmer.ID, existingFarmer.FarmerID); err != nil {
+				log.Println("❌ Customer ID job enqueue failed:", err)
+			}
 		}
 
 		return c.Status(fiber.StatusOK).JSON(
@@ -328,25 +274,22 @@ func CreateCustomerDetailHandler(c *fiber.Ctx) error {
 	newDetail.CustomGeographyStructure1ID = payload.CustomGeo1ID
 	newDetail.CustomGeographyStructure2ID = payload.CustomGeo2ID
 
-	if err := initializers.DB.Create(&newDetail).Error; err != nil {
+	// ----------------------------------------------------
+	// 8. SAVE + QUEUE CUSTOMER ID GENERATION (same transaction)
+	// ----------------------------------------------------
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newDetail).Error; err != nil {
+			return err
+		}
+		return enqueueCustomerIDJob(tx, coopId, newDetail.ID, newDetail.FarmerID)
+	})
+	if err != nil {
 		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
 			"status":  "error",
 			"message": err.Error(),
 		})
 	}
 
-	// ----------------------------------------------------
-	// 8. ASYNC CUSTOMER ID GENERATION
-	// ----------------------------------------------------
-	ctx := context.Background()
-	q := query.Use(initializers.DB)
-
-	go func(id uint) {
-		if _, err := GenerateAndSetNextCustomerIDGen(ctx, q, id); err != nil {
-			log.Println("❌ Customer ID generation failed:", err)
-		}
-	}(newDetail.ID)
-
 	// ----------------------------------------------------
 	// 9. RESPONSE
 	// ----------------------------------------------------
@@ -367,7 +310,7 @@ func CreateCustomerDetailHandler(c *fiber.Ctx) error {
 }
 
 func SendCustomerErrorResponse(c *fiber.Ctx, msg string, farmerId string) error {
-	now := time.Now().UTC()
+	now := clock.Now().UTC()
 	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 		"success": false,
 		"data": fiber.Map{
@@ -435,7 +378,11 @@ func FindCustomerDetailsHandler(c *fiber.Ctx) error {
 			})
 		}
 
-		query = query.Where("cust_id_update_at>= ? AND cust_id_update_at<= ? ", fromTime, toTime)
+		// ID assignment and later profile changes both count as an update
+		query = query.Where(
+			"((cust_id_update_at>= ? AND cust_id_update_at<= ?) OR (details_update_at>= ? AND details_update_at<= ?))",
+			fromTime, toTime, fromTime, toTime,
+		)
 	}
 	query.Count(&totalRecords)
 
@@ -463,6 +410,7 @@ func FindCustomerDetailsHandler(c *fiber.Ctx) error {
 			FarmerId:  f.FarmerID,
 			CreatedAt: f.CreatedAt.Format("2006-01-02T15:04:05Z"),
 			UpdatedAt: f.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			Active:    f.Active,
 		})
 	}
 
@@ -505,6 +453,13 @@ func CreateVendorDetailHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if payload.BankDetails != nil {
+		payload.BankDetails.Normalize()
+		if err := payload.BankDetails.Validate(); err != nil {
+			return SendVendorErrorResponse(c, err.Error(), payload.FarmerID)
+		}
+	}
+
 	// ----------------------------------------------------
 	// 3. Farmer exists in SAME coop but vendor not created
 	// ----------------------------------------------------
@@ -518,15 +473,17 @@ func CreateVendorDetailHandler(c *fiber.Ctx) error {
 		Error
 
 	if err == nil {
+		if payload.BankDetails != nil {
+			existingFarmer.SetBankDetails(*payload.BankDetails)
+			if err := saveFarmerDetails(&existingFarmer); err != nil {
+				return sendFarmerStoreError(c, err)
+			}
+		}
+
 		if existingFarmer.VendorID == "" {
-			ctx := context.Background()
-			q := query.Use(initializers.DB)
-
-			go func(id uint) {
-				if _, err := GenerateAndSetNextVendorIDGen(ctx, q, id); err != nil {
-					log.Println("❌ Vendor ID generation failed:", err)
-				}
-			}(existingFarmer.ID)
+			if err := enqueueVendorIDJob(initializers.DB, coopId, existingFarmer.ID, existingFarmer.FarmerID); err != nil {
+				log.Println("❌ Vendor ID job enqueue failed:", err)
+			}
 		}
 
 		return c.Status(fiber.StatusOK).JSON(
@@ -642,26 +599,26 @@ func CreateVendorDetailHandler(c *fiber.Ctx) error {
 		RaithuCreatedDate:           payload.RaithuCreatedDate,
 		RaithuUpdatedAt:             payload.RaithuUpdatedAt,
 	}
+	if payload.BankDetails != nil {
+		newDetail.SetBankDetails(*payload.BankDetails)
+	}
 
-	if err := initializers.DB.Create(&newDetail).Error; err != nil {
+	// ----------------------------------------------------
+	// 9. SAVE + QUEUE VENDOR ID GENERATION (same transaction)
+	// ----------------------------------------------------
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newDetail).Error; err != nil {
+			return err
+		}
+		return enqueueVendorIDJob(tx, coopId, newDetail.ID, newDetail.FarmerID)
+	})
+	if err != nil {
 		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
 			"status":  "error",
 			"message": err.Error(),
 		})
 	}
 
-	// ----------------------------------------------------
-	// 9. ASYNC VENDOR ID GENERATION
-	// ----------------------------------------------------
-	ctx := context.Background()
-	q := query.Use(initializers.DB)
-
-	go func(id uint) {
-		if _, err := GenerateAndSetNextVendorIDGen(ctx, q, id); err != nil {
-			log.Println("❌ Vendor ID gen failed:", err)
-		}
-	}(newDetail.ID)
-
 	// ----------------------------------------------------
 	// 10. RESPONSE
 	// ----------------------------------------------------
@@ -682,7 +639,7 @@ func CreateVendorDetailHandler(c *fiber.Ctx) error {
 }
 
 func SendVendorErrorResponse(c *fiber.Ctx, msg string, farmerId string) error {
-	now := time.Now().UTC()
+	now := clock.Now().UTC()
 	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 		"success": false,
 		"data": fiber.Map{
@@ -750,7 +707,11 @@ func FindVendorDetailsHandler(c *fiber.Ctx) error {
 			})
 		}
 
-		query = query.Where("vendor_id_update_at>= ? AND vendor_id_update_at<= ? ", fromTime, toTime)
+		// ID assignment and later profile changes both count as an update
+		query = query.Where(
+			"((vendor_id_update_at>= ? AND vendor_id_update_at<= ?) OR (details_update_at>= ? AND details_update_at<= ?))",
+			fromTime, toTime, fromTime, toTime,
+		)
 	}
 
 	query.Count(&totalRecords)
@@ -779,6 +740,7 @@ func FindVendorDetailsHandler(c *fiber.Ctx) error {
 			FarmerId:  f.FarmerID,
 			CreatedAt: f.CreatedAt.Format("2006-01-02T15:04:05Z"),
 			UpdatedAt: f.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			Active:    f.Active,
 		})
 	}
 
@@ -864,6 +826,7 @@ func GetCustomerDetailHandler(c *fiber.Ctx) error {
 		VendorCode:         farmer.VendorID,
 		CreatedDate:        farmer.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedDate:        farmer.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		Active:             farmer.Active,
 		BankDetails: models.BankDetailsInfo{
 			IBAN:  "", // ensure field exists
 			SWIFT: "", // ensure field exists
@@ -943,11 +906,296 @@ func GetVendorDetailHandler(c *fiber.Ctx) error {
 		VendorCode:         farmer.VendorID,
 		CreatedDate:        farmer.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedDate:        farmer.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		BankDetails: models.BankDetailsInfo{
-			IBAN:  "", // ensure field exists
-			SWIFT: "", // ensure field exists
-		},
+		Active:             farmer.Active,
+		BankDetails:        farmer.BankDetails(),
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response)
 }
+
+// findCoopFarmer loads the farmer record of farmerId in coopId and returns the
+// ERP error message when it can't
+func findCoopFarmer(coopId, farmerId string, farmer *models.FarmerDetails) string {
+	if !isCoopAllowed(coopId) {
+		return "The indicated cooperative does not exist."
+	}
+
+	err := initializers.DB.
+		Where("coop_id = ? AND farmer_id = ?", coopId, farmerId).
+		First(farmer).
+		Error
+	if err != nil {
+		return "The indicated FarmerId does not exist."
+	}
+	return ""
+}
+
+// patchFarmerDetail applies a PATCH body to the farmer with the same rules as
+// creation. It returns the ERP error message when the change is rejected.
+func patchFarmerDetail(coopId, farmerId string, payload *models.UpdateDetailSchema) (models.FarmerDetails, string, error) {
+	var farmer models.FarmerDetails
+
+	// 1. Load the farmer of this coop
+	if msg := findCoopFarmer(coopId, farmerId, &farmer); msg != "" {
+		return farmer, msg, nil
+	}
+	previousKycID := farmer.FarmerKycID
+
+	if payload.BankDetails != nil {
+		payload.BankDetails.Normalize()
+		if err := payload.BankDetails.Validate(); err != nil {
+			return farmer, err.Error(), nil
+		}
+	}
+
+	// 2. Merge the patch and re-check the create validations
+	payload.Apply(&farmer)
+
+	if farmer.FirstName == "" || farmer.LastName == "" {
+		return farmer, "You must provide the first and last name.", nil
+	}
+
+	if farmer.FarmerKycID == "" && farmer.ClubLeaderFarmerID == "" {
+		return farmer, "Either farmer_kyc_id or clubLeaderFarmerId must be provided.", nil
+	}
+
+	// 3. KYC uniqueness → a KYC ID may only belong to one farmer
+	if farmer.FarmerKycID != "" && farmer.FarmerKycID != previousKycID {
+		var kycFarmer models.FarmerDetails
+
+		err := initializers.DB.
+			Where("farmer_kyc_id = ? AND farmer_id <> ?", farmer.FarmerKycID, farmer.FarmerID).
+			First(&kycFarmer).
+			Error
+		if err == nil {
+			return farmer, "Farmer with the given KYC ID " + farmer.FarmerKycID + " already exists.", nil
+		}
+	}
+
+	// 4. Save; details_update_at makes the change visible to updatedFrom/updatedTo
+	now := clock.Now()
+	farmer.DetailsUpdateAt = &now
+
+	return farmer, "", saveFarmerDetails(&farmer)
+}
+
+// setFarmerActive deactivates or reactivates the farmer. Repeating the current
+// state is a no-op.
+func setFarmerActive(coopId, farmerId string, active bool) (models.FarmerDetails, string, error) {
+	var farmer models.FarmerDetails
+
+	if msg := findCoopFarmer(coopId, farmerId, &farmer); msg != "" {
+		return farmer, msg, nil
+	}
+
+	if farmer.Active == active {
+		return farmer, "", nil
+	}
+
+	now := clock.Now()
+	farmer.Active = active
+	farmer.DetailsUpdateAt = &now
+	if active {
+		farmer.DeactivatedAt = nil
+	} else {
+		farmer.DeactivatedAt = &now
+	}
+
+	return farmer, "", saveFarmerDetails(&farmer)
+}
+
+// saveFarmerDetails stores the profile columns only; the ERP IDs belong to the
+// ID jobs and an empty ID must stay NULL for the unique indexes
+func saveFarmerDetails(farmer *models.FarmerDetails) error {
+	return initializers.DB.
+		Omit("CustomerID", "VendorID", "CustIDUpdateAt", "VendorIDUpdateAt").
+		Save(farmer).
+		Error
+}
+
+func sendFarmerCustomerResponse(c *fiber.Ctx, farmer models.FarmerDetails, msg string) error {
+	return c.Status(fiber.StatusOK).JSON(
+		models.CreateSuccessFarmerCustomerResponse{
+			Success: true,
+			Data: models.CreateFarmerCustomerResponse{
+				TempERPCustomerID: farmer.TempID,
+				ErpCustomerId:     farmer.CustomerID,
+				FarmerId:          farmer.FarmerID,
+				CreatedAt:         farmer.CreatedAt.Format(time.RFC3339),
+				UpdatedAt:         farmer.UpdatedAt.Format(time.RFC3339),
+				Message:           msg,
+			},
+		},
+	)
+}
+
+func sendFarmerVendorResponse(c *fiber.Ctx, farmer models.FarmerDetails, msg string) error {
+	return c.Status(fiber.StatusOK).JSON(
+		models.CreateSuccessFarmerVendorResponse{
+			Success: true,
+			Data: models.CreateFarmerVendorResponse{
+				TempERPCustomerID: farmer.TempID,
+				ErpVendorId:       farmer.VendorID,
+				FarmerId:          farmer.FarmerID,
+				CreatedAt:         farmer.CreatedAt.Format(time.RFC3339),
+				UpdatedAt:         farmer.UpdatedAt.Format(time.RFC3339),
+				Message:           msg,
+			},
+		},
+	)
+}
+
+func sendFarmerStoreError(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
+
+// PatchCustomerDetailHandler handles PATCH /spic_to_erp/customers/:coopId/farmers/:farmerId
+// @Summary      Update a farmer
+// @Description  Change the fields present in the body; KYC IDs stay unique across farmers
+// @Tags         customers
+// @Accept       json
+// @Produce      json
+// @Param        coopId    path      string                     true  "Cooperative ID"
+// @Param        farmerId  path      string                     true  "Farmer ID"
+// @Param        detail    body      models.UpdateDetailSchema  true  "Fields to change"
+// @Success      200       {object}  models.CreateSuccessFarmerCustomerResponse
+// @Router       /spic_to_erp/customers/{coopId}/farmers/{farmerId} [patch]
+func PatchCustomerDetailHandler(c *fiber.Ctx) error {
+	coopId := c.Params("coopId")
+	farmerId := c.Params("farmerId")
+
+	var payload *models.UpdateDetailSchema
+	if err := c.BodyParser(&payload); err != nil || payload == nil {
+		return SendCustomerErrorResponse(c, "Invalid request body.", farmerId)
+	}
+
+	if payload.BankDetails != nil {
+		return SendCustomerErrorResponse(c, "Bank details can only be changed through the vendors API.", farmerId)
+	}
+
+	farmer, msg, err := patchFarmerDetail(coopId, farmerId, payload)
+	if err != nil {
+		return sendFarmerStoreError(c, err)
+	}
+	if msg != "" {
+		return SendCustomerErrorResponse(c, msg, farmerId)
+	}
+
+	return sendFarmerCustomerResponse(c, farmer, "Farmer detail updated successfully")
+}
+
+// DeactivateCustomerDetailHandler handles POST /spic_to_erp/customers/:coopId/farmers/:farmerId/deactivate
+// @Summary      Deactivate a farmer
+// @Tags         customers
+// @Produce      json
+// @Param        coopId    path      string  true  "Cooperative ID"
+// @Param        farmerId  path      string  true  "Farmer ID"
+// @Success      200       {object}  models.CreateSuccessFarmerCustomerResponse
+// @Router       /spic_to_erp/customers/{coopId}/farmers/{farmerId}/deactivate [post]
+func DeactivateCustomerDetailHandler(c *fiber.Ctx) error {
+	return customerActiveHandler(c, false)
+}
+
+// ReactivateCustomerDetailHandler handles POST /spic_to_erp/customers/:coopId/farmers/:farmerId/reactivate
+// @Summary      Reactivate a farmer
+// @Tags         customers
+// @Produce      json
+// @Param        coopId    path      string  true  "Cooperative ID"
+// @Param        farmerId  path      string  true  "Farmer ID"
+// @Success      200       {object}  models.CreateSuccessFarmerCustomerResponse
+// @Router       /spic_to_erp/customers/{coopId}/farmers/{farmerId}/reactivate [post]
+func ReactivateCustomerDetailHandler(c *fiber.Ctx) error {
+	return customerActiveHandler(c, true)
+}
+
+func customerActiveHandler(c *fiber.Ctx, active bool) error {
+	farmerId := c.Params("farmerId")
+
+	farmer, msg, err := setFarmerActive(c.Params("coopId"), farmerId, active)
+	if err != nil {
+		return sendFarmerStoreError(c, err)
+	}
+	if msg != "" {
+		return SendCustomerErrorResponse(c, msg, farmerId)
+	}
+
+	if active {
+		return sendFarmerCustomerResponse(c, farmer, "Farmer reactivated successfully")
+	}
+	return sendFarmerCustomerResponse(c, farmer, "Farmer deactivated successfully")
+}
+
+// PatchVendorDetailHandler handles PATCH /spic_to_erp/vendors/:coopId/farmers/:farmerId
+// @Summary      Update a farmer
+// @Description  Change the fields present in the body; KYC IDs stay unique across farmers
+// @Tags         vendors
+// @Accept       json
+// @Produce      json
+// @Param        coopId    path      string                     true  "Cooperative ID"
+// @Param        farmerId  path      string                     true  "Farmer ID"
+// @Param        detail    body      models.UpdateDetailSchema  true  "Fields to change"
+// @Success      200       {object}  models.CreateSuccessFarmerVendorResponse
+// @Router       /spic_to_erp/vendors/{coopId}/farmers/{farmerId} [patch]
+func PatchVendorDetailHandler(c *fiber.Ctx) error {
+	coopId := c.Params("coopId")
+	farmerId := c.Params("farmerId")
+
+	var payload *models.UpdateDetailSchema
+	if err := c.BodyParser(&payload); err != nil || payload == nil {
+		return SendVendorErrorResponse(c, "Invalid request body.", farmerId)
+	}
+
+	farmer, msg, err := patchFarmerDetail(coopId, farmerId, payload)
+	if err != nil {
+		return sendFarmerStoreError(c, err)
+	}
+	if msg != "" {
+		return SendVendorErrorResponse(c, msg, farmerId)
+	}
+
+	return sendFarmerVendorResponse(c, farmer, "Farmer detail updated successfully")
+}
+
+// DeactivateVendorDetailHandler handles POST /spic_to_erp/vendors/:coopId/farmers/:farmerId/deactivate
+// @Summary      Deactivate a farmer
+// @Tags         vendors
+// @Produce      json
+// @Param        coopId    path      string  true  "Cooperative ID"
+// @Param        farmerId  path      string  true  "Farmer ID"
+// @Success      200       {object}  models.CreateSuccessFarmerVendorResponse
+// @Router       /spic_to_erp/vendors/{coopId}/farmers/{farmerId}/deactivate [post]
+func DeactivateVendorDetailHandler(c *fiber.Ctx) error {
+	return vendorActiveHandler(c, false)
+}
+
+// ReactivateVendorDetailHandler handles POST /spic_to_erp/vendors/:coopId/farmers/:farmerId/reactivate
+// @Summary      Reactivate a farmer
+// @Tags         vendors
+// @Produce      json
+// @Param        coopId    path      string  true  "Cooperative ID"
+// @Param        farmerId  path      string  true  "Farmer ID"
+// @Success      200       {object}  models.CreateSuccessFarmerVendorResponse
+// @Router       /spic_to_erp/vendors/{coopId}/farmers/{farmerId}/reactivate [post]
+func ReactivateVendorDetailHandler(c *fiber.Ctx) error {
+	return vendorActiveHandler(c, true)
+}
+
+func vendorActiveHandler(c *fiber.Ctx, active bool) error {
+	farmerId := c.Params("farmerId")
+
+	farmer, msg, err := setFarmerActive(c.Params("coopId"), farmerId, active)
+	if err != nil {
+		return sendFarmerStoreError(c, err)
+	}
+	if msg != "" {
+		return SendVendorErrorResponse(c, msg, farmerId)
+	}
+
+	if active {
+		return sendFarmerVendorResponse(c, farmer, "Farmer reactivated successfully")
+	}
+	return sendFarmerVendorResponse(c, farmer, "Farmer deactivated successfully")
+}
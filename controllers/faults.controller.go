@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/models/faults"
+)
+
+// ListFaultsHandler handles GET /admin/faults
+// @Summary      List fault injection rules
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/faults [get]
+func ListFaultsHandler(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    faults.List(),
+	})
+}
+
+// CreateFaultHandler handles POST /admin/faults
+// @Summary      Add a fault injection rule
+// @Description  Matches /spic_to_erp requests by method, route pattern, coopId and body fields and injects latency, a fixed status, a dropped connection or a corrupted body
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        rule  body      faults.Rule  true  "Fault rule"
+// @Success      201   {object}  map[string]interface{}
+// @Router       /admin/faults [post]
+func CreateFaultHandler(c *fiber.Ctx) error {
+	var payload faults.Rule
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if err := payload.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    faults.Add(payload),
+	})
+}
+
+// GetFaultHandler handles GET /admin/faults/:faultId
+// @Summary      Get a fault injection rule
+// @Tags         admin
+// @Produce      json
+// @Param        faultId  path  int  true  "Rule ID"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/faults/{faultId} [get]
+func GetFaultHandler(c *fiber.Ctx) error {
+	id, _ := strconv.ParseUint(c.Params("faultId"), 10, 64)
+
+	rule, ok := faults.Get(uint(id))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Fault rule not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// DeleteFaultHandler handles DELETE /admin/faults/:faultId
+// @Summary      Remove a fault injection rule
+// @Tags         admin
+// @Param        faultId  path  int  true  "Rule ID"
+// @Success      204
+// @Router       /admin/faults/{faultId} [delete]
+func DeleteFaultHandler(c *fiber.Ctx) error {
+	id, _ := strconv.ParseUint(c.Params("faultId"), 10, 64)
+
+	if !faults.Delete(uint(id)) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Fault rule not found",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ClearFaultsHandler handles DELETE /admin/faults
+// @Summary      Remove all fault injection rules
+// @Tags         admin
+// @Success      204
+// @Router       /admin/faults [delete]
+func ClearFaultsHandler(c *fiber.Ctx) error {
+	faults.Clear()
+	return c.SendStatus(fiber.StatusNoContent)
+}
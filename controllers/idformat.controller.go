@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+)
+
+// GenerateERPIdentifier renders the next identifier for formatKey (e.g.
+// initializers.CustomerIDFormat) using the cooperative's template.
+// Cooperatives with their own template also get their own counter.
+func GenerateERPIdentifier(
+	ctx context.Context,
+	formatKey string,
+	sequenceName string,
+	coopId string,
+) (string, error) {
+
+	format, perCoop, err := initializers.ResolveIDFormat(formatKey, coopId)
+	if err != nil {
+		return "", err
+	}
+
+	scope := ""
+	if perCoop {
+		scope = coopId
+	}
+
+	return format.Generate(initializers.DB.WithContext(ctx), sequenceName, scope, coopId, clock.Now())
+}
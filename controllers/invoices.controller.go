@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/delivery"
+	"github.com/shyamsundaar/karino-mock-server/models/deliveryproof"
+	"github.com/shyamsundaar/karino-mock-server/models/invoices"
+	"github.com/shyamsundaar/karino-mock-server/models/jobs"
+	"github.com/shyamsundaar/karino-mock-server/models/products"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
+	"gorm.io/gorm"
+)
+
+// formatInvoiceDate renders an optional invoice or delivery document date
+func formatInvoiceDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// buildWaybillInvoice prices the items of a delivery proof into a pending
+// invoice. Each item is matched to its delivery document line by stock
+// keeping unit and billed at the price and tax rate of that sales order
+// item; items without a match fall back to the proof's unit_price.
+func buildWaybillInvoice(db *gorm.DB, waybill *deliveryproof.Waybill, items []deliveryproof.WaybillItem) (*invoices.Invoice, error) {
+	invoice := invoices.Invoice{
+		CoopID:             waybill.CoopID,
+		WaybillID:          waybill.ID,
+		OrderID:            waybill.OrderID,
+		DeliveryDocumentID: waybill.DeliveryNoteID,
+		Status:             invoices.StatusPending,
+	}
+	if coop, ok := initializers.LookupCooperative(waybill.CoopID); ok {
+		invoice.Currency = coop.Currency
+	}
+
+	var documentLines []delivery.CreateDeliveryDocuments
+	if err := db.
+		Where("coop_id = ? AND order_id = ? AND delivery_document_id = ?", waybill.CoopID, waybill.OrderID, waybill.DeliveryNoteID).
+		Find(&documentLines).Error; err != nil {
+		return nil, err
+	}
+	documentLineBySKU := make(map[string]delivery.CreateDeliveryDocuments, len(documentLines))
+	for _, line := range documentLines {
+		documentLineBySKU[line.StockKeppingUnit] = line
+	}
+	if len(documentLines) > 0 {
+		invoice.DeliveryDocumentCode = documentLines[0].DeliveryDocumentCode
+		invoice.DeliveryDocumentDate = documentLines[0].CreatedAt
+	}
+
+	var orderItems []sales.SalesOrderItem
+	if err := db.Where("order_id = ?", waybill.OrderID).Find(&orderItems).Error; err != nil {
+		return nil, err
+	}
+	orderItemByID := make(map[string]sales.SalesOrderItem, len(orderItems))
+	for _, item := range orderItems {
+		orderItemByID[item.OrderItemID] = item
+	}
+
+	var rules []products.TaxRule
+	if err := db.Where("coop_id IN ?", []string{"", waybill.CoopID}).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		line := invoices.InvoiceLine{
+			ErpItemID:        item.ErpItemID,
+			StockKeepingUnit: item.StockKeepingUnit,
+			Name:             item.Name,
+			Quantity:         item.Quantity,
+		}
+
+		documentLine, matched := documentLineBySKU[item.StockKeepingUnit]
+		orderItem, priced := orderItemByID[documentLine.OrderItemID]
+		if matched && priced {
+			line.OrderItemID = orderItem.OrderItemID
+			line.DeliveryNoteItemID = orderItem.ErpItemID2
+			line.UnitPrice = orderItem.UnitPrice
+			line.TaxRate = orderItem.TaxRate
+		} else {
+			line.UnitPrice, _ = strconv.ParseFloat(item.UnitPrice, 64)
+			line.TaxRate = resolveTaxRate(rules, waybill.CoopID, &products.Product{})
+		}
+
+		invoice.Lines = append(invoice.Lines, line)
+	}
+
+	invoice.SetTotals()
+	return &invoice, nil
+}
+
+// createWaybillInvoice stores the proof's invoice and queues its issue
+// (ERP ID, code and date) after INVOICE_TIME_SECONDS. It runs in the
+// transaction that stores the proof, so a proof never lacks its invoice.
+func createWaybillInvoice(tx *gorm.DB, waybill *deliveryproof.Waybill, items []deliveryproof.WaybillItem) (*jobs.Job, error) {
+	invoice, err := buildWaybillInvoice(tx, waybill, items)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Create(invoice).Error; err != nil {
+		return nil, err
+	}
+	return initializers.EnqueueJob(tx, jobs.TypeInvoice, invoice.CoopID, invoice.ID, invoice.OrderID,
+		time.Duration(initializers.AppConfig.InvoiceTimeSeconds)*time.Second)
+}
+
+// issueWaybillInvoice issues the invoice of a committed proof before the
+// proof request returns when INVOICE_TIME_SECONDS=0
+func issueWaybillInvoice(job *jobs.Job) {
+	if initializers.AppConfig.InvoiceTimeSeconds > 0 {
+		return
+	}
+	// A worker may have claimed the job already; it issues the invoice then
+	if _, err := initializers.RunJobNow(initializers.DB, job.ID); err != nil && !errors.Is(err, initializers.ErrJobNotRunnable) {
+		log.Println("❌ Invoice issue failed:", err)
+	}
+}
+
+// RunInvoiceJob issues an invoice: it assigns the ERP invoice ID and code
+// from their templates and dates it, then marks the order INVOICED once all
+// its delivery documents are. Values already set are kept, so a retry only
+// fills in what the previous attempt missed.
+func RunInvoiceJob(ctx context.Context, job *jobs.Job) error {
+	var invoice invoices.Invoice
+	if err := initializers.DB.WithContext(ctx).First(&invoice, job.EntityID).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{}
+	if invoice.ErpInvoiceID == "" {
+		id, err := GenerateERPIdentifier(ctx, initializers.InvoiceIDFormat, sequences.InvoiceID, invoice.CoopID)
+		if err != nil {
+			return err
+		}
+		updates["erp_invoice_id"] = id
+	}
+	if invoice.ErpInvoiceCode == "" {
+		code, err := GenerateERPIdentifier(ctx, initializers.InvoiceCodeFormat, sequences.InvoiceCode, invoice.CoopID)
+		if err != nil {
+			return err
+		}
+		updates["erp_invoice_code"] = code
+	}
+	if invoice.InvoiceDate == nil {
+		updates["invoice_date"] = clock.Now().UTC()
+	}
+	updates["status"] = invoices.StatusIssued
+
+	return initializers.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&invoice).Updates(updates).Error; err != nil {
+			return err
+		}
+		return markSalesOrderInvoiced(tx, invoice.CoopID, invoice.OrderID)
+	})
+}
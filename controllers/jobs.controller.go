@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/jobs"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"github.com/shyamsundaar/karino-mock-server/query"
+	"gorm.io/gorm"
+)
+
+// RegisterJobHandlers wires the ERP ID assignment and invoice jobs into the job queue
+func RegisterJobHandlers() {
+	initializers.RegisterJobHandler(jobs.TypeCustomerID, RunCustomerIDJob)
+	initializers.RegisterJobHandler(jobs.TypeVendorID, RunVendorIDJob)
+	initializers.RegisterJobHandler(jobs.TypeSalesOrder, RunSalesOrderIDJob)
+	initializers.RegisterJobHandler(jobs.TypeInvoice, RunInvoiceJob)
+}
+
+func RunCustomerIDJob(ctx context.Context, job *jobs.Job) error {
+	_, err := GenerateAndSetNextCustomerIDGen(ctx, query.Use(initializers.DB), job.EntityID)
+	return err
+}
+
+func RunVendorIDJob(ctx context.Context, job *jobs.Job) error {
+	_, err := GenerateAndSetNextVendorIDGen(ctx, query.Use(initializers.DB), job.EntityID)
+	return err
+}
+
+// RunSalesOrderIDJob assigns both the ERP sales order ID and code, which
+// confirms a received order. Both generators skip values that are already
+// set, so a retry only fills in what the previous attempt missed.
+func RunSalesOrderIDJob(ctx context.Context, job *jobs.Job) error {
+	q := query.Use(initializers.DB)
+
+	if _, err := GenerateAndSetNextErpSalesOrderIDGen(ctx, q, job.EntityID); err != nil {
+		return err
+	}
+	if _, err := GenerateAndSetNextErpSalesOrderCodeGen(ctx, q, job.EntityID); err != nil {
+		return err
+	}
+
+	return initializers.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order sales.SalesOrder
+		if err := tx.First(&order, job.EntityID).Error; err != nil {
+			return err
+		}
+		// Fail the attempt, so it is retried, rather than confirm without IDs
+		if order.ErpSalesOrderId == "" || order.ErpSalesOrderCode == "" {
+			return fmt.Errorf("sales order %s: ERP sales order ID or code missing", order.OrderID)
+		}
+		// An order cancelled before its IDs were assigned stays cancelled
+		if order.Status != sales.StatusReceived {
+			return nil
+		}
+		return transitionSalesOrder(tx, &order, sales.StatusConfirmed, "ERP sales order ID assigned")
+	})
+}
+
+// enqueueCustomerIDJob schedules the customer ID assignment of a farmer. Like
+// the vendor and sales order jobs, its business delay (CUSTOMER_TIME_SECONDS)
+// becomes the job's first run time instead of a sleep inside the generator.
+func enqueueCustomerIDJob(db *gorm.DB, coopId string, detailID uint, farmerId string) error {
+	_, err := initializers.EnqueueJob(db, jobs.TypeCustomerID, coopId, detailID, farmerId,
+		time.Duration(initializers.AppConfig.CustomerTimeSeconds)*time.Second)
+	return err
+}
+
+func enqueueVendorIDJob(db *gorm.DB, coopId string, detailID uint, farmerId string) error {
+	_, err := initializers.EnqueueJob(db, jobs.TypeVendorID, coopId, detailID, farmerId,
+		time.Duration(initializers.AppConfig.VendorTimeSeconds)*time.Second)
+	return err
+}
+
+func enqueueSalesOrderIDJob(db *gorm.DB, coopId string, orderDBID uint, orderId string) error {
+	_, err := initializers.EnqueueJob(db, jobs.TypeSalesOrder, coopId, orderDBID, orderId,
+		time.Duration(initializers.AppConfig.SalesTimeSeconds)*time.Second)
+	return err
+}
+
+func toJobResponse(j *jobs.Job) jobs.JobResponse {
+	completedAt := ""
+	if j.CompletedAt != nil {
+		completedAt = j.CompletedAt.UTC().Format(time.RFC3339)
+	}
+
+	return jobs.JobResponse{
+		ID:          j.ID,
+		Type:        j.Type,
+		CoopID:      j.CoopID,
+		EntityID:    j.EntityID,
+		EntityRef:   j.EntityRef,
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt.UTC().Format(time.RFC3339),
+		LastError:   j.LastError,
+		CreatedAt:   j.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   j.UpdatedAt.UTC().Format(time.RFC3339),
+		CompletedAt: completedAt,
+	}
+}
+
+func sendJobError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadGateway
+	switch {
+	case errors.Is(err, initializers.ErrJobNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, initializers.ErrJobNotRunnable):
+		status = fiber.StatusConflict
+	}
+
+	return c.Status(status).JSON(jobs.ErrorJobResponse{
+		Success: false,
+		Message: err.Error(),
+	})
+}
+
+func parseJobID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("jobId"), 10, 64)
+	if err != nil {
+		return 0, initializers.ErrJobNotFound
+	}
+	return uint(id), nil
+}
+
+// ListJobsHandler handles GET /admin/jobs
+// @Summary      List ERP ID assignment jobs
+// @Description  Filter background jobs by cooperative, entity, type and status
+// @Tags         admin
+// @Produce      json
+// @Param        coopId     query  string  false  "Cooperative ID"
+// @Param        type       query  string  false  "CUSTOMER_ID, VENDOR_ID or SALES_ORDER_ID"
+// @Param        status     query  string  false  "PENDING, RUNNING, FAILED or COMPLETED"
+// @Param        entityRef  query  string  false  "farmerId or orderId"
+// @Param        entityId   query  int     false  "Database ID of the farmer detail / sales order"
+// @Param        page       query  int     false  "Page number"    default(1)
+// @Param        perPage    query  int     false  "Items per page" default(10)
+// @Success      200  {object}  jobs.ListJobsResponse
+// @Router       /admin/jobs [get]
+func ListJobsHandler(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("perPage", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if perPage <= 0 {
+		perPage = 10
+	}
+	offset := (page - 1) * perPage
+
+	query := initializers.DB.Model(&jobs.Job{})
+
+	if coopId := c.Query("coopId"); coopId != "" {
+		query = query.Where("coop_id = ?", coopId)
+	}
+	if jobType := c.Query("type"); jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if entityRef := c.Query("entityRef"); entityRef != "" {
+		query = query.Where("entity_ref = ?", entityRef)
+	}
+	if entityId := c.Query("entityId"); entityId != "" {
+		query = query.Where("entity_id = ?", entityId)
+	}
+
+	var totalRecords int64
+	query.Count(&totalRecords)
+
+	var rows []jobs.Job
+	if err := query.
+		Order("id DESC").
+		Limit(perPage).
+		Offset(offset).
+		Find(&rows).Error; err != nil {
+		return sendJobError(c, err)
+	}
+
+	totalPages := int(math.Ceil(float64(totalRecords) / float64(perPage)))
+
+	data := make([]jobs.JobResponse, 0, len(rows))
+	for i := range rows {
+		data = append(data, toJobResponse(&rows[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(jobs.ListJobsResponse{
+		Data: data,
+		Pagination: jobs.PaginationInfo{
+			Page:        page,
+			Limit:       perPage,
+			TotalItems:  int(totalRecords),
+			TotalPages:  totalPages,
+			HasPrevious: page > 1,
+			HasNext:     page < totalPages,
+		},
+	})
+}
+
+// GetJobHandler handles GET /admin/jobs/:jobId
+// @Summary      Get an ERP ID assignment job
+// @Tags         admin
+// @Produce      json
+// @Param        jobId  path  int  true  "Job ID"
+// @Success      200  {object}  jobs.JobDetailResponse
+// @Failure      404  {object}  jobs.ErrorJobResponse
+// @Router       /admin/jobs/{jobId} [get]
+func GetJobHandler(c *fiber.Ctx) error {
+	id, err := parseJobID(c)
+	if err != nil {
+		return sendJobError(c, err)
+	}
+
+	var job jobs.Job
+	if err := initializers.DB.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = initializers.ErrJobNotFound
+		}
+		return sendJobError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(jobs.JobDetailResponse{
+		Success: true,
+		Data:    toJobResponse(&job),
+	})
+}
+
+// RetryJobHandler handles POST /admin/jobs/:jobId/retry
+// @Summary      Retry an ERP ID assignment job
+// @Description  Makes a pending or failed job due immediately; failed jobs get a fresh set of attempts
+// @Tags         admin
+// @Produce      json
+// @Param        jobId  path  int  true  "Job ID"
+// @Success      202  {object}  jobs.JobDetailResponse
+// @Failure      404  {object}  jobs.ErrorJobResponse
+// @Failure      409  {object}  jobs.ErrorJobResponse
+// @Router       /admin/jobs/{jobId}/retry [post]
+func RetryJobHandler(c *fiber.Ctx) error {
+	id, err := parseJobID(c)
+	if err != nil {
+		return sendJobError(c, err)
+	}
+
+	job, err := initializers.RetryJob(initializers.DB, id)
+	if err != nil {
+		return sendJobError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(jobs.JobDetailResponse{
+		Success: true,
+		Data:    toJobResponse(job),
+	})
+}
+
+// RunJobHandler handles POST /admin/jobs/:jobId/run
+// @Summary      Complete an ERP ID assignment job now
+// @Description  Runs a pending or failed job synchronously, skipping its remaining delay, and returns the outcome
+// @Tags         admin
+// @Produce      json
+// @Param        jobId  path  int  true  "Job ID"
+// @Success      200  {object}  jobs.JobDetailResponse
+// @Failure      404  {object}  jobs.ErrorJobResponse
+// @Failure      409  {object}  jobs.ErrorJobResponse
+// @Router       /admin/jobs/{jobId}/run [post]
+func RunJobHandler(c *fiber.Ctx) error {
+	id, err := parseJobID(c)
+	if err != nil {
+		return sendJobError(c, err)
+	}
+
+	job, err := initializers.RunJobNow(initializers.DB, id)
+	if err != nil {
+		return sendJobError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(jobs.JobDetailResponse{
+		Success: job.Status == jobs.StatusCompleted,
+		Data:    toJobResponse(job),
+	})
+}
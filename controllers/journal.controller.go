@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/faults"
+	"github.com/shyamsundaar/karino-mock-server/models/journal"
+)
+
+// SearchJournalHandler handles GET /admin/journal
+// @Summary      Search recorded /spic_to_erp traffic
+// @Description  Searches the in-memory journal buffer, newest first
+// @Tags         admin
+// @Produce      json
+// @Param        method   query  string  false  "HTTP method"
+// @Param        route    query  string  false  "Route pattern (/spic_to_erp/customers/:coopId/farmers) or exact path"
+// @Param        coopId   query  string  false  "Cooperative ID"
+// @Param        from     query  string  false  "ISO8601 lower bound (inclusive)"
+// @Param        to       query  string  false  "ISO8601 upper bound (inclusive)"
+// @Param        status   query  string  false  "Exact status (201) or class (4xx)"
+// @Param        body     query  string  false  "Substring of the request body"
+// @Param        limit    query  int     false  "Maximum entries" default(100)
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/journal [get]
+func SearchJournalHandler(c *fiber.Ctx) error {
+	method := c.Query("method")
+	route := c.Query("route")
+	coopId := c.Query("coopId")
+	status := strings.ToLower(c.Query("status"))
+	body := c.Query("body")
+
+	limit, _ := strconv.Atoi(c.Query("limit", "100"))
+
+	var from, to time.Time
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"Message": "Invalid from format. Use ISO8601 (YYYY-MM-DDTHH:MM:SSZ)",
+			})
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"Message": "Invalid to format. Use ISO8601 (YYYY-MM-DDTHH:MM:SSZ)",
+			})
+		}
+	}
+
+	entries := initializers.Journal.Search(func(e *journal.Entry) bool {
+		if method != "" && !strings.EqualFold(method, e.Method) {
+			return false
+		}
+		if route != "" && route != e.Route && !faults.MatchRoute(route, e.Path) {
+			return false
+		}
+		if coopId != "" && coopId != e.CoopID {
+			return false
+		}
+		if !from.IsZero() && e.Time.Before(from) {
+			return false
+		}
+		if !to.IsZero() && e.Time.After(to) {
+			return false
+		}
+		if status != "" && !matchStatus(status, e.Status) {
+			return false
+		}
+		if body != "" && !strings.Contains(e.RequestBody, body) {
+			return false
+		}
+		return true
+	}, limit)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"count":   len(entries),
+		"data":    entries,
+	})
+}
+
+// matchStatus accepts "503" or a class such as "5xx"
+func matchStatus(filter string, status int) bool {
+	if len(filter) == 3 && strings.HasSuffix(filter, "xx") {
+		return strconv.Itoa(status/100) == filter[:1]
+	}
+	return filter == strconv.Itoa(status)
+}
+
+// GetJournalEntryHandler handles GET /admin/journal/:entryId
+// @Summary      Get one recorded request/response
+// @Tags         admin
+// @Produce      json
+// @Param        entryId  path  int  true  "Entry ID"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/journal/{entryId} [get]
+func GetJournalEntryHandler(c *fiber.Ctx) error {
+	id, _ := strconv.ParseUint(c.Params("entryId"), 10, 64)
+
+	entry, ok := initializers.Journal.Get(id)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Journal entry not found (it may have left the buffer)",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    entry,
+	})
+}
+
+// ClearJournalHandler handles DELETE /admin/journal
+// @Summary      Empty the in-memory journal buffer
+// @Description  The JSONL file is kept
+// @Tags         admin
+// @Success      204
+// @Router       /admin/journal [delete]
+func ClearJournalHandler(c *fiber.Ctx) error {
+	initializers.Journal.Clear()
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/apikeys"
+	"github.com/shyamsundaar/karino-mock-server/oauth"
+)
+
+func oauthError(c *fiber.Ctx, status int, code, description string) error {
+	if status == fiber.StatusUnauthorized {
+		c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	}
+	return c.Status(status).JSON(oauth.ErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// basicCredentials reads client_id/client_secret from HTTP Basic auth
+func basicCredentials(c *fiber.Ctx) (string, string, bool) {
+	scheme, encoded, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	id, secret, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return "", "", false
+	}
+	// RFC 6749 2.3.1: both parts are form-urlencoded
+	if v, err := url.QueryUnescape(id); err == nil {
+		id = v
+	}
+	if v, err := url.QueryUnescape(secret); err == nil {
+		secret = v
+	}
+	return id, secret, true
+}
+
+// IssueTokenHandler handles POST /oauth/token
+// @Summary      Issue an access token (client credentials grant)
+// @Description  Clients authenticate with HTTP Basic or client_id/client_secret in the body. The master client is OAUTH_CLIENT_ID with the APIKey as secret; tenant clients use an API key's prefix as client_id and the key as secret. The token carries the client's coops and the requested scopes.
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Accept       json
+// @Produce      json
+// @Param        request  body      oauth.TokenRequest  true  "Token request"
+// @Success      200      {object}  oauth.TokenResponse
+// @Failure      400      {object}  oauth.ErrorResponse
+// @Failure      401      {object}  oauth.ErrorResponse
+// @Router       /oauth/token [post]
+func IssueTokenHandler(c *fiber.Ctx) error {
+	// 1. Parse the grant
+	var payload oauth.TokenRequest
+	if err := c.BodyParser(&payload); err != nil {
+		return oauthError(c, fiber.StatusBadRequest, "invalid_request", err.Error())
+	}
+	if payload.GrantType != "client_credentials" {
+		return oauthError(c, fiber.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+	}
+
+	clientID, clientSecret := payload.ClientID, payload.ClientSecret
+	if id, secret, ok := basicCredentials(c); ok {
+		clientID, clientSecret = id, secret
+	}
+	if clientID == "" || clientSecret == "" {
+		return oauthError(c, fiber.StatusUnauthorized, "invalid_client", "client credentials are required")
+	}
+
+	// 2. Authenticate the client: the master client or a tenant API key
+	claims := oauth.Claims{Subject: clientID}
+	var allowed []string
+	switch {
+	case clientID == initializers.OAuthClientID() && clientSecret == initializers.AppConfig.ApiKey:
+		claims.Coops = []string{apikeys.AllCoops}
+		allowed = []string{apikeys.AllScopes}
+
+	default:
+		var key apikeys.APIKey
+		err := initializers.DB.Where("key_hash = ?", apikeys.Hash(clientSecret)).First(&key).Error
+		if err != nil || key.Prefix != clientID || !key.Active(clock.Now()) {
+			return oauthError(c, fiber.StatusUnauthorized, "invalid_client", "client authentication failed")
+		}
+		claims.KeyID = key.ID
+		claims.Coops = key.Coops
+		allowed = key.Scopes
+	}
+
+	// 3. Requested scopes must be covered by the client's; none means all of them
+	granted := strings.Fields(payload.Scope)
+	if len(granted) == 0 {
+		granted = allowed
+	}
+	for _, s := range granted {
+		if !apikeys.ScopeGranted(allowed, s) {
+			return oauthError(c, fiber.StatusBadRequest, "invalid_scope", "scope "+s+" is not granted to this client")
+		}
+	}
+
+	// 4. Sign
+	lifetime := initializers.TokenLifetime()
+	if payload.ExpiresIn > 0 && time.Duration(payload.ExpiresIn)*time.Second < lifetime {
+		lifetime = time.Duration(payload.ExpiresIn) * time.Second
+	}
+
+	now := clock.Now()
+	claims.Issuer = oauth.Issuer
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(lifetime).Unix()
+	claims.ID = uuid.New().String()
+	claims.Scope = strings.Join(granted, " ")
+
+	token, err := oauth.Sign(claims, initializers.TokenSecret())
+	if err != nil {
+		return oauthError(c, fiber.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderPragma, "no-cache")
+	return c.Status(fiber.StatusOK).JSON(oauth.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(lifetime / time.Second),
+		Scope:       claims.Scope,
+	})
+}
@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/deliveryproof"
+	"github.com/shyamsundaar/karino-mock-server/models/photos"
+	"gorm.io/gorm"
+)
+
+const (
+	// Multipart form fields of a proof or photo upload
+	proofFormField    = "proof"
+	photoFormField    = "photos"
+	checksumFormField = "sha256"
+)
+
+// isMultipart reports whether the request body is multipart/form-data
+func isMultipart(c *fiber.Ctx) bool {
+	return strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm)
+}
+
+// photoURL is where a stored photo is downloaded from
+func photoURL(coopId, deliveryNoteId, photoId string) string {
+	return fmt.Sprintf("/spic_to_erp/customers/%s/deliverydocuments/%s/photos/%s", coopId, deliveryNoteId, photoId)
+}
+
+// linkedPhotos turns the legacy url1/url2 proof fields into photos
+func linkedPhotos(urls ...string) []photos.Photo {
+	return photos.Linked(clock.Now().UTC(), urls...)
+}
+
+// pendingPhoto is an uploaded photo that passed validation but is not in
+// the blob store yet
+type pendingPhoto struct {
+	photo photos.Photo
+	data  []byte
+}
+
+// checkUploadedPhotos validates the form's photos, including their sha256
+// values, without writing anything. The message is set when a photo is rejected.
+func checkUploadedPhotos(form *multipart.Form, coopId, deliveryNoteId string, existing int) ([]pendingPhoto, string, error) {
+	files := form.File[photoFormField]
+	checksums := form.Value[checksumFormField]
+
+	if existing+len(files) > initializers.AppConfig.PhotoMaxCount {
+		return nil, fmt.Sprintf("A waybill can have at most %d photos.", initializers.AppConfig.PhotoMaxCount), nil
+	}
+	if len(checksums) > 0 && len(checksums) != len(files) {
+		return nil, "Send one sha256 value per photo, in the same order.", nil
+	}
+
+	pending := make([]pendingPhoto, 0, len(files))
+	for i, file := range files {
+		if file.Size > initializers.Photos.MaxBytes() {
+			return nil, fmt.Sprintf("Photo %q is too large (max %d bytes).", file.Filename, initializers.Photos.MaxBytes()), nil
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		data, err := io.ReadAll(io.LimitReader(f, initializers.Photos.MaxBytes()+1))
+		f.Close()
+		if err != nil {
+			return nil, "", err
+		}
+
+		blob, err := initializers.Photos.Check(data)
+		if err != nil {
+			return nil, fmt.Sprintf("Photo %q is rejected: %v.", file.Filename, err), nil
+		}
+		if len(checksums) > 0 && !strings.EqualFold(strings.TrimSpace(checksums[i]), blob.SHA256) {
+			return nil, fmt.Sprintf("Photo %q does not match its sha256 checksum.", file.Filename), nil
+		}
+
+		id := uuid.NewString()
+		pending = append(pending, pendingPhoto{
+			photo: photos.Photo{
+				ID:          id,
+				URL:         photoURL(coopId, deliveryNoteId, id),
+				FileName:    file.Filename,
+				ContentType: blob.ContentType,
+				Size:        blob.Size,
+				SHA256:      blob.SHA256,
+				UploadedAt:  clock.Now().UTC(),
+			},
+			data: data,
+		})
+	}
+	return pending, "", nil
+}
+
+// pendingPhotoList is the photo list of validated uploads
+func pendingPhotoList(pending []pendingPhoto) []photos.Photo {
+	list := make([]photos.Photo, 0, len(pending))
+	for _, p := range pending {
+		list = append(list, p.photo)
+	}
+	return list
+}
+
+// storePendingPhotos writes validated photos to the blob store. Callers run
+// it last in the transaction that links the photos, so a rejected or failed
+// request leaves no unreferenced blob behind.
+func storePendingPhotos(pending []pendingPhoto) error {
+	for _, p := range pending {
+		if _, err := initializers.Photos.Put(p.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDeliveryWaybill loads the proof (waybill) of a delivery document. When
+// there is none it writes the error response and returns false.
+func findDeliveryWaybill(c *fiber.Ctx, db *gorm.DB, coopId, deliveryNoteId string, waybill *deliveryproof.Waybill) (bool, error) {
+	if !isCoopAllowed(coopId) {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "The indicated cooperative does not exist.",
+		})
+	}
+
+	err := db.Where("coop_id = ? AND delivery_note_id = ?", coopId, deliveryNoteId).First(waybill).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "No delivery proof found for the indicated delivery document.",
+		})
+	}
+	if err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+	return true, nil
+}
+
+// AddDeliveryPhotosHandler handles POST /spic_to_erp/customers/:coopId/deliverydocuments/:deliveryNoteId/photos
+// @Summary      Upload photos to a delivery proof
+// @Description  multipart/form-data with any number of "photos" files and optionally one "sha256" value per photo
+// @Tags         deliverydocuments proof
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        coopId path      string  true   " "
+// @Param        deliveryNoteId path      string  true   " "
+// @Param        photos formData  file    true   "Photo (repeatable)"
+// @Param        sha256 formData  string  false  "SHA-256 of the photo (repeatable)"
+// @Success      201    {object}  photos.PhotoListResponse
+// @Router       /spic_to_erp/customers/{coopId}/deliverydocuments/{deliveryNoteId}/photos [post]
+func AddDeliveryPhotosHandler(c *fiber.Ctx) error {
+	coopId := c.Params("coopId")
+	deliveryNoteId := c.Params("deliveryNoteId")
+
+	var waybill deliveryproof.Waybill
+	if found, err := findDeliveryWaybill(c, initializers.DB, coopId, deliveryNoteId, &waybill); !found {
+		return err
+	}
+
+	if !isMultipart(c) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Send the photos as multipart/form-data.",
+		})
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+	if len(form.File[photoFormField]) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "You must send at least one photo.",
+		})
+	}
+
+	pending, msg, err := checkUploadedPhotos(form, coopId, deliveryNoteId, len(waybill.DeliveryPhotos))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": msg,
+		})
+	}
+
+	// Re-read inside the transaction so concurrent uploads append to the latest list
+	uploaded := pendingPhotoList(pending)
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&waybill, waybill.ID).Error; err != nil {
+			return err
+		}
+		if len(waybill.DeliveryPhotos)+len(uploaded) > initializers.AppConfig.PhotoMaxCount {
+			msg = fmt.Sprintf("A waybill can have at most %d photos.", initializers.AppConfig.PhotoMaxCount)
+			return nil
+		}
+		waybill.DeliveryPhotos = append(waybill.DeliveryPhotos, uploaded...)
+		if err := tx.Model(&waybill).Select("DeliveryPhotos").Updates(&waybill).Error; err != nil {
+			return err
+		}
+		return storePendingPhotos(pending)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": msg,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(photos.PhotoListResponse{Photos: uploaded})
+}
+
+// GetDeliveryPhotosHandler handles GET /spic_to_erp/customers/:coopId/deliverydocuments/:deliveryNoteId/photos
+// @Summary      List the photos of a delivery proof
+// @Description  List the photos of a delivery proof
+// @Tags         deliverydocuments proof
+// @Produce      json
+// @Param        coopId path      string  true   " "
+// @Param        deliveryNoteId path      string  true   " "
+// @Success      200    {object}  photos.PhotoListResponse
+// @Router       /spic_to_erp/customers/{coopId}/deliverydocuments/{deliveryNoteId}/photos [get]
+func GetDeliveryPhotosHandler(c *fiber.Ctx) error {
+	var waybill deliveryproof.Waybill
+	if found, err := findDeliveryWaybill(c, initializers.DB, c.Params("coopId"), c.Params("deliveryNoteId"), &waybill); !found {
+		return err
+	}
+
+	list := waybill.DeliveryPhotos
+	if list == nil {
+		list = make([]photos.Photo, 0)
+	}
+	return c.Status(fiber.StatusOK).JSON(photos.PhotoListResponse{Photos: list})
+}
+
+// DownloadDeliveryPhotoHandler handles GET /spic_to_erp/customers/:coopId/deliverydocuments/:deliveryNoteId/photos/:photoId
+// @Summary      Download a delivery photo
+// @Description  Returns the stored bytes, or redirects to the URL of a linked (url1/url2) photo
+// @Tags         deliverydocuments proof
+// @Produce      image/jpeg,image/png,image/webp
+// @Param        coopId path      string  true   " "
+// @Param        deliveryNoteId path      string  true   " "
+// @Param        photoId path      string  true   " "
+// @Success      200
+// @Router       /spic_to_erp/customers/{coopId}/deliverydocuments/{deliveryNoteId}/photos/{photoId} [get]
+func DownloadDeliveryPhotoHandler(c *fiber.Ctx) error {
+	var waybill deliveryproof.Waybill
+	if found, err := findDeliveryWaybill(c, initializers.DB, c.Params("coopId"), c.Params("deliveryNoteId"), &waybill); !found {
+		return err
+	}
+
+	var photo *photos.Photo
+	for i := range waybill.DeliveryPhotos {
+		if waybill.DeliveryPhotos[i].ID == c.Params("photoId") {
+			photo = &waybill.DeliveryPhotos[i]
+			break
+		}
+	}
+	if photo == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Photo not found.",
+		})
+	}
+	if !photo.Stored() {
+		return c.Redirect(photo.URL, fiber.StatusFound)
+	}
+
+	etag := `"` + photo.SHA256 + `"`
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	data, err := initializers.Photos.Get(photo.SHA256)
+	if errors.Is(err, photos.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Photo is missing from the photo store.",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, photo.ContentType)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", photo.FileName))
+	return c.Status(fiber.StatusOK).Send(data)
+}
@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/products"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"gorm.io/gorm"
+)
+
+// defaultTaxRate (percent) applies when neither a tax rule nor the coop's
+// taxRate covers a line; it is the flat rate orders used to get
+const defaultTaxRate = 5.0
+
+// priceSalesOrder sets the totals of order with items. In pricelist mode each
+// item gets the product's list price, whatever unit_price the client sent,
+// and the tax rate resolved by resolveTaxRate. PRICING_MODE=random
+// keeps the legacy random amounts: new orders get a random value and
+// amendments (order.ID != 0) scale it.
+func priceSalesOrder(db *gorm.DB, order *sales.SalesOrder, oldItems, items []sales.SalesOrderItem) error {
+	if coop, ok := initializers.LookupCooperative(order.CoopID); ok {
+		order.Currency = coop.Currency
+	}
+
+	if initializers.RandomPricing() {
+		if order.ID == 0 {
+			order.RandomizeTotals()
+		} else {
+			order.Reprice(oldItems, items)
+		}
+		return nil
+	}
+
+	var rules []products.TaxRule
+	if err := db.Where("coop_id IN ?", []string{"", order.CoopID}).Find(&rules).Error; err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := &items[i]
+
+		product := products.Product{ProductCode: item.ProductGroup}
+		err := db.Where("product_code = ?", item.ProductGroup).First(&product).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		item.UnitPrice = product.UnitPrice
+		item.TaxRate = resolveTaxRate(rules, order.CoopID, &product)
+	}
+
+	order.PriceItems(items)
+	return nil
+}
+
+// resolveTaxRate picks the rate of a line, most specific first: a rule for
+// the coop and product group, a coop-wide rule, a product-group-wide rule,
+// the coop's own taxRate (when set), a catch-all rule, defaultTaxRate. At the
+// same level a rule for the product code beats one for its tax category.
+func resolveTaxRate(rules []products.TaxRule, coopId string, product *products.Product) float64 {
+	best := 0
+	byCode := false
+	rate := 0.0
+	for _, rule := range rules {
+		match := rule.Match(coopId, product)
+		if match == 0 {
+			continue
+		}
+		code := rule.ProductGroup == product.ProductCode
+		if best == 0 || match < best || (match == best && code && !byCode) {
+			best, byCode, rate = match, code, rule.Rate
+		}
+	}
+
+	if best != 0 && best <= products.MatchGroup {
+		return rate
+	}
+	if coop, ok := initializers.LookupCooperative(coopId); ok && coop.TaxRate > 0 {
+		return coop.TaxRate
+	}
+	if best == products.MatchAny {
+		return rate
+	}
+	return defaultTaxRate
+}
+
+// ListPriceListHandler handles GET /admin/pricelist
+// @Summary      List product prices
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/pricelist [get]
+func ListPriceListHandler(c *fiber.Ctx) error {
+	var list []products.Product
+	if err := initializers.DB.Order("product_code").Find(&list).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// SetPriceHandler handles PUT /admin/pricelist/:productCode
+// @Summary      Set a product's list price
+// @Description  Applies to orders priced from now on; existing orders keep their item prices
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        productCode  path      string                   true  "Product code"
+// @Param        price        body      products.SetPriceSchema  true  "Price"
+// @Success      200          {object}  map[string]interface{}
+// @Router       /admin/pricelist/{productCode} [put]
+func SetPriceHandler(c *fiber.Ctx) error {
+	var payload products.SetPriceSchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+	if payload.UnitPrice < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "unit_price must not be negative",
+		})
+	}
+
+	var product products.Product
+	err := initializers.DB.Where("product_code = ?", c.Params("productCode")).First(&product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Product not found",
+		})
+	}
+	if err == nil {
+		product.UnitPrice = payload.UnitPrice
+		err = initializers.DB.Save(&product).Error
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    product,
+	})
+}
+
+// findTaxRule loads the tax rule of the path. When there is none it writes
+// the error response and returns false.
+func findTaxRule(c *fiber.Ctx, rule *products.TaxRule) (bool, error) {
+	id, _ := strconv.ParseUint(c.Params("ruleId"), 10, 64)
+	err := initializers.DB.First(rule, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Tax rule not found",
+		})
+	}
+	if err != nil {
+		return false, c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	return true, nil
+}
+
+// ListTaxRulesHandler handles GET /admin/taxrules
+// @Summary      List tax rules
+// @Tags         admin
+// @Produce      json
+// @Param        coopId  query  string  false  "Only rules of this coop (and the ones for every coop)"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/taxrules [get]
+func ListTaxRulesHandler(c *fiber.Ctx) error {
+	db := initializers.DB.Order("coop_id, product_group")
+	if coopId := c.Query("coopId"); coopId != "" {
+		db = db.Where("coop_id IN ?", []string{"", coopId})
+	}
+
+	var list []products.TaxRule
+	if err := db.Find(&list).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// CreateTaxRuleHandler handles POST /admin/taxrules
+// @Summary      Create a tax rule
+// @Description  Leave coopId or productGroup empty to match any
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        rule  body      products.CreateTaxRuleSchema  true  "Tax rule"
+// @Success      201   {object}  map[string]interface{}
+// @Router       /admin/taxrules [post]
+func CreateTaxRuleHandler(c *fiber.Ctx) error {
+	var payload products.CreateTaxRuleSchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	rule := products.TaxRule{
+		CoopID:       payload.CoopID,
+		ProductGroup: payload.ProductGroup,
+		Rate:         payload.Rate,
+	}
+	rule.Normalize()
+	if err := rule.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	var count int64
+	initializers.DB.Model(&products.TaxRule{}).
+		Where("coop_id = ? AND product_group = ?", rule.CoopID, rule.ProductGroup).
+		Count(&count)
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "A tax rule for this coopId and productGroup already exists",
+		})
+	}
+
+	if err := initializers.DB.Create(&rule).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// GetTaxRuleHandler handles GET /admin/taxrules/:ruleId
+// @Summary      Get a tax rule
+// @Tags         admin
+// @Produce      json
+// @Param        ruleId  path  int  true  "Tax rule ID"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/taxrules/{ruleId} [get]
+func GetTaxRuleHandler(c *fiber.Ctx) error {
+	var rule products.TaxRule
+	if found, err := findTaxRule(c, &rule); !found {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// UpdateTaxRuleHandler handles PUT /admin/taxrules/:ruleId
+// @Summary      Change a tax rule's rate
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        ruleId  path      int                           true  "Tax rule ID"
+// @Param        rule    body      products.UpdateTaxRuleSchema  true  "Rate"
+// @Success      200     {object}  map[string]interface{}
+// @Router       /admin/taxrules/{ruleId} [put]
+func UpdateTaxRuleHandler(c *fiber.Ctx) error {
+	var rule products.TaxRule
+	if found, err := findTaxRule(c, &rule); !found {
+		return err
+	}
+
+	var payload products.UpdateTaxRuleSchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	rule.Rate = payload.Rate
+	if err := rule.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if err := initializers.DB.Save(&rule).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// DeleteTaxRuleHandler handles DELETE /admin/taxrules/:ruleId
+// @Summary      Delete a tax rule
+// @Tags         admin
+// @Param        ruleId  path  int  true  "Tax rule ID"
+// @Success      204
+// @Router       /admin/taxrules/{ruleId} [delete]
+func DeleteTaxRuleHandler(c *fiber.Ctx) error {
+	var rule products.TaxRule
+	if found, err := findTaxRule(c, &rule); !found {
+		return err
+	}
+
+	if err := initializers.DB.Delete(&rule).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
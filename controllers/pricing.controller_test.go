@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/shyamsundaar/karino-mock-server/models/products"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestResolveTaxRate(t *testing.T) {
+	fertilizer := &products.Product{ProductCode: "IIT-101", TaxCategory: "FERTILIZER"}
+	seed := &products.Product{ProductCode: "IIT-102"}
+
+	tests := []struct {
+		name    string
+		rules   []products.TaxRule
+		coopId  string
+		product *products.Product
+		want    float64
+	}{
+		{
+			name:    "no rules uses the default",
+			coopId:  "COOP019",
+			product: seed,
+			want:    defaultTaxRate,
+		},
+		{
+			name:    "catch-all rule",
+			rules:   []products.TaxRule{{Rate: 9}},
+			coopId:  "COOP019",
+			product: seed,
+			want:    9,
+		},
+		{
+			name: "coop and group beats coop-wide and group-wide",
+			rules: []products.TaxRule{
+				{CoopID: "COOP019", Rate: 10},
+				{ProductGroup: "IIT-101", Rate: 11},
+				{CoopID: "COOP019", ProductGroup: "IIT-101", Rate: 12},
+				{Rate: 1},
+			},
+			coopId:  "COOP019",
+			product: fertilizer,
+			want:    12,
+		},
+		{
+			name: "coop-wide beats group-wide",
+			rules: []products.TaxRule{
+				{ProductGroup: "IIT-101", Rate: 11},
+				{CoopID: "COOP019", Rate: 10},
+			},
+			coopId:  "COOP019",
+			product: fertilizer,
+			want:    10,
+		},
+		{
+			name: "rules of other coops and products are ignored",
+			rules: []products.TaxRule{
+				{CoopID: "COOP029", Rate: 10},
+				{ProductGroup: "IIT-102", Rate: 11},
+			},
+			coopId:  "COOP019",
+			product: fertilizer,
+			want:    defaultTaxRate,
+		},
+		{
+			name:    "tax category matches like the product code",
+			rules:   []products.TaxRule{{ProductGroup: "FERTILIZER", Rate: 7}},
+			coopId:  "COOP019",
+			product: fertilizer,
+			want:    7,
+		},
+		{
+			name: "product code beats tax category at the same level",
+			rules: []products.TaxRule{
+				{ProductGroup: "FERTILIZER", Rate: 7},
+				{ProductGroup: "IIT-101", Rate: 8},
+			},
+			coopId:  "COOP019",
+			product: fertilizer,
+			want:    8,
+		},
+		{
+			name: "product code beats tax category in any order",
+			rules: []products.TaxRule{
+				{ProductGroup: "IIT-101", Rate: 8},
+				{ProductGroup: "FERTILIZER", Rate: 7},
+			},
+			coopId:  "COOP019",
+			product: fertilizer,
+			want:    8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveTaxRate(tt.rules, tt.coopId, tt.product); got != tt.want {
+				t.Errorf("resolveTaxRate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceSalesOrderIgnoresClientUnitPrice(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&products.Product{}, &products.TaxRule{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&products.Product{ProductCode: "IIT-101", UnitPrice: 100})
+	db.Create(&products.TaxRule{Rate: 10})
+
+	order := sales.SalesOrder{CoopID: "COOP019"}
+	items := []sales.SalesOrderItem{
+		{OrderItemID: "I1", ProductGroup: "IIT-101", Quantity: 2, UnitPrice: 1},
+		{OrderItemID: "I2", ProductGroup: "IIT-101", Quantity: 1, UnitPrice: 500},
+	}
+	if err := priceSalesOrder(db, &order, nil, items); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range items {
+		if item.UnitPrice != 100 {
+			t.Errorf("item %s: unit price = %v, want the list price 100", item.OrderItemID, item.UnitPrice)
+		}
+	}
+	if order.OrderValue != 300 || order.TaxAmount != 30 || order.TotalAmount != 330 {
+		t.Errorf("totals = %v + %v = %v, want 300 + 30 = 330", order.OrderValue, order.TaxAmount, order.TotalAmount)
+	}
+}
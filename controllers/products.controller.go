@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/products"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"gorm.io/gorm"
+)
+
+// findProduct loads the product of the path. When there is none it writes
+// the error response and returns false.
+func findProduct(c *fiber.Ctx, product *products.Product) (bool, error) {
+	err := initializers.DB.Where("product_code = ?", c.Params("productCode")).First(product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Product not found",
+		})
+	}
+	if err != nil {
+		return false, c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	return true, nil
+}
+
+// ListProductsHandler handles GET /admin/products
+// @Summary      List the product catalog
+// @Tags         admin
+// @Produce      json
+// @Param        active       query  bool    false  "Only active (true) or inactive (false) products"
+// @Param        coopId       query  string  false  "Only products available to this coop"
+// @Param        taxCategory  query  string  false  "Only products of this tax category"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/products [get]
+func ListProductsHandler(c *fiber.Ctx) error {
+	db := initializers.DB.Order("product_code")
+	switch c.Query("active") {
+	case "true":
+		db = db.Where("active = ?", true)
+	case "false":
+		db = db.Where("active = ?", false)
+	}
+	if category := c.Query("taxCategory"); category != "" {
+		db = db.Where("tax_category = ?", category)
+	}
+
+	var list []products.Product
+	if err := db.Find(&list).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	// Coops is a JSON column; filter availability in Go like the API keys list
+	if coopId := c.Query("coopId"); coopId != "" {
+		available := make([]products.Product, 0, len(list))
+		for _, product := range list {
+			if product.AvailableIn(coopId) {
+				available = append(available, product)
+			}
+		}
+		list = available
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// CreateProductHandler handles POST /admin/products
+// @Summary      Add a product to the catalog
+// @Description  Sales order items reference it by product_group; leave coops empty to offer it to every coop
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        product  body      products.CreateProductSchema  true  "Product"
+// @Success      201      {object}  map[string]interface{}
+// @Router       /admin/products [post]
+func CreateProductHandler(c *fiber.Ctx) error {
+	var payload products.CreateProductSchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	active := payload.Active == nil || *payload.Active
+	product := products.Product{
+		ProductCode:   payload.ProductCode,
+		Name:          payload.Name,
+		UnitOfMeasure: payload.UnitOfMeasure,
+		UnitPrice:     payload.UnitPrice,
+		TaxCategory:   payload.TaxCategory,
+		Active:        &active,
+		Coops:         payload.Coops,
+	}
+	product.Normalize()
+	if err := product.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if product.ProductCode != "" {
+			var count int64
+			if err := tx.Model(&products.Product{}).Where("product_code = ?", product.ProductCode).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return gorm.ErrDuplicatedKey
+			}
+		}
+		return tx.Create(&product).Error
+	})
+	// A concurrent create with the same code trips the unique index instead
+	if errors.Is(err, gorm.ErrDuplicatedKey) || initializers.IsDuplicateKey(err) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Product " + product.ProductCode + " already exists",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    product,
+	})
+}
+
+// GetProductHandler handles GET /admin/products/:productCode
+// @Summary      Get a product
+// @Tags         admin
+// @Produce      json
+// @Param        productCode  path  string  true  "Product code"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/products/{productCode} [get]
+func GetProductHandler(c *fiber.Ctx) error {
+	var product products.Product
+	if found, err := findProduct(c, &product); !found {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    product,
+	})
+}
+
+// UpdateProductHandler handles PUT /admin/products/:productCode
+// @Summary      Update a product
+// @Description  Omitted fields are kept. Price changes apply to orders priced from now on.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        productCode  path      string                        true  "Product code"
+// @Param        product      body      products.UpdateProductSchema  true  "Changes"
+// @Success      200          {object}  map[string]interface{}
+// @Router       /admin/products/{productCode} [put]
+func UpdateProductHandler(c *fiber.Ctx) error {
+	var product products.Product
+	if found, err := findProduct(c, &product); !found {
+		return err
+	}
+
+	var payload products.UpdateProductSchema
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if payload.Name != nil {
+		product.Name = *payload.Name
+	}
+	if payload.UnitOfMeasure != nil {
+		product.UnitOfMeasure = *payload.UnitOfMeasure
+	}
+	if payload.UnitPrice != nil {
+		product.UnitPrice = *payload.UnitPrice
+	}
+	if payload.TaxCategory != nil {
+		product.TaxCategory = *payload.TaxCategory
+	}
+	if payload.Active != nil {
+		product.Active = payload.Active
+	}
+	if payload.Coops != nil {
+		product.Coops = *payload.Coops
+	}
+	product.Normalize()
+	if err := product.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if err := initializers.DB.Save(&product).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    product,
+	})
+}
+
+// DeleteProductHandler handles DELETE /admin/products/:productCode
+// @Summary      Remove a product
+// @Description  Products on existing orders can't be removed; deactivate them instead
+// @Tags         admin
+// @Param        productCode  path  string  true  "Product code"
+// @Success      204
+// @Router       /admin/products/{productCode} [delete]
+func DeleteProductHandler(c *fiber.Ctx) error {
+	var product products.Product
+	if found, err := findProduct(c, &product); !found {
+		return err
+	}
+
+	var lines int64
+	initializers.DB.Model(&sales.SalesOrderItem{}).Where("product_group = ?", product.ProductCode).Count(&lines)
+	if lines > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Product " + product.ProductCode + " is used by sales orders; set active to false instead",
+		})
+	}
+
+	if err := initializers.DB.Delete(&product).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -1,30 +1,34 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"math"
 	"strconv"
 
 	// "strings"
 	"context"
 	"fmt"
-	"log"
-	"regexp"
 	"time"
 
 	// "database/sql"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/shyamsundaar/karino-mock-server/clock"
 	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/delivery"
 	models "github.com/shyamsundaar/karino-mock-server/models/farmers"
 	"github.com/shyamsundaar/karino-mock-server/models/products"
 	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
 
 	// "github.com/google/uuid"
 	// "github.com/shyamsundaar/karino-mock-server/models/farmers"
 	// "karino-mock-server/query"
 	"github.com/shyamsundaar/karino-mock-server/query"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GenerateAndSetNextErpSalesOrderIDGen(
@@ -35,35 +39,52 @@ func GenerateAndSetNextErpSalesOrderIDGen(
 	so := q.SalesOrder.WithContext(ctx)
 
 	// 1. Fetch current sales order row
-	_, err := so.
+	row, err := so.
 		Where(q.SalesOrder.ID.Eq(salesOrderID)).
 		First()
 	if err != nil {
 		return "", err
 	}
 
-	// 4. Generate new ERP Sales Order ID
-	newErpSalesOrderID := uuid.New().String()
+	// 2. If already generated → return
+	if row.ErpSalesOrderId != "" {
+		return row.ErpSalesOrderId, nil
+	}
 
-	// 5. Business delay
-	time.Sleep(time.Duration(initializers.AppConfig.SalesTimeSeconds) * time.Second)
+	// 3. Generate new ERP Sales Order ID from the coop's template (default UUID)
+	newErpSalesOrderID, err := GenerateERPIdentifier(ctx, initializers.SalesOrderIDFormat, sequences.ErpSalesOrderID, row.CoopID)
+	if err != nil {
+		return "", err
+	}
 
 	// 6. Update ONLY if still empty (race-condition safe)
-	_, err = so.
+	info, err := so.
 		Where(
 			q.SalesOrder.ID.Eq(salesOrderID),
-			q.SalesOrder.ErpSalesOrderId.Eq(""),
+			q.SalesOrder.ErpSalesOrderId.IsNull(),
 		).
 		UpdateColumnSimple(
 			q.SalesOrder.ErpSalesOrderId.Value(newErpSalesOrderID),
-			q.SalesOrder.UpdatedAt.Value(time.Now()),
-			q.SalesOrder.IdUpdatedAt.Value(time.Now()),
+			q.SalesOrder.UpdatedAt.Value(clock.Now()),
+			q.SalesOrder.IdUpdatedAt.Value(clock.Now()),
 		)
 
 	if err != nil {
 		return "", err
 	}
 
+	// 7. Nothing updated → a concurrent run stored one, or the column is not NULL
+	if info.RowsAffected == 0 {
+		row, err := so.Where(q.SalesOrder.ID.Eq(salesOrderID)).First()
+		if err != nil {
+			return "", err
+		}
+		if row.ErpSalesOrderId == "" {
+			return "", fmt.Errorf("sales order %d: ERP sales order ID was not stored", salesOrderID)
+		}
+		return row.ErpSalesOrderId, nil
+	}
+
 	return newErpSalesOrderID, nil
 }
 
@@ -88,42 +109,43 @@ func GenerateAndSetNextErpSalesOrderCodeGen(
 		return row.ErpSalesOrderCode, nil
 	}
 
-	next := 1
-
-	last, err := so.
-		Where(q.SalesOrder.ErpSalesOrderCode.Neq("")).
-		Order(q.SalesOrder.ID.Desc()).
-		First()
-
-	if err == nil && last.ErpSalesOrderCode != "" {
-		re := regexp.MustCompile(`\d+$`)
-		if m := re.FindString(last.ErpSalesOrderCode); m != "" {
-			n, _ := strconv.Atoi(m)
-			next = n + 1
-		}
+	// 3. Generate code from the coop's template (default ECL 2025/n)
+	newErpSalesOrderCode, err := GenerateERPIdentifier(ctx, initializers.SalesOrderCodeFormat, sequences.ErpSalesOrderCode, row.CoopID)
+	if err != nil {
+		return "", err
 	}
 
-	newErpSalesOrderCode := fmt.Sprintf("ECL 2025/%d", next)
-
 	// 5. Business delay
 	// time.Sleep(time.Duration(initializers.AppConfig.TimeSeconds) * time.Second)
 
 	// 6. Update ONLY if still empty (race-condition safe)
-	_, err = so.
+	info, err := so.
 		Where(
 			q.SalesOrder.ID.Eq(ErpSalesOrderCode),
-			q.SalesOrder.ErpSalesOrderCode.Eq(""),
+			q.SalesOrder.ErpSalesOrderCode.IsNull(),
 		).
 		UpdateColumnSimple(
 			q.SalesOrder.ErpSalesOrderCode.Value(newErpSalesOrderCode),
-			q.SalesOrder.UpdatedAt.Value(time.Now()),
-			q.SalesOrder.IdUpdatedAt.Value(time.Now()),
+			q.SalesOrder.UpdatedAt.Value(clock.Now()),
+			q.SalesOrder.IdUpdatedAt.Value(clock.Now()),
 		)
 
 	if err != nil {
 		return "", err
 	}
 
+	// 7. Nothing updated → a concurrent run stored one, or the column is not NULL
+	if info.RowsAffected == 0 {
+		row, err := so.Where(q.SalesOrder.ID.Eq(ErpSalesOrderCode)).First()
+		if err != nil {
+			return "", err
+		}
+		if row.ErpSalesOrderCode == "" {
+			return "", fmt.Errorf("sales order %d: ERP sales order code was not stored", ErpSalesOrderCode)
+		}
+		return row.ErpSalesOrderCode, nil
+	}
+
 	return newErpSalesOrderCode, nil
 }
 
@@ -148,7 +170,6 @@ func CreateCustomerSalesOrderHandler(c *fiber.Ctx) error {
 	// 2. Parse request body
 	var payload *sales.CreateSalesOrderSchema
 	var existingSalesOrder sales.SalesOrder
-	var existingFarmer models.FarmerDetails
 	if err := c.BodyParser(&payload); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(sales.ErrorSalesOrderResponse{
 			Success: false,
@@ -171,146 +192,41 @@ func CreateCustomerSalesOrderHandler(c *fiber.Ctx) error {
 		return SendSalesErrorResponse(c, "The OrderId already exist.", payload.OrderID)
 	}
 
-	if payload.FarmerID == "" {
-		return SendSalesErrorResponse(c, "You must provide the FarmerID.", payload.OrderID)
-	}
-
-	if payload.ContractID == "" {
-		return SendSalesErrorResponse(c, "You must provide the ContractID.", payload.OrderID)
-	}
-
-	farmerId := initializers.DB.
-		Where(
-			"farmer_id = ? AND coop_id = ?",
-			payload.FarmerID,
-			coopId,
-		).
-		First(&existingFarmer).
-		Error
-
-	if farmerId != nil {
-		return SendSalesErrorResponse(c, "The indicated FarmerId does not exist.", payload.OrderID)
-	}
-
-	for _, item := range payload.OrderItems {
-
-		if item.OrderItemID == "" {
-			return SendSalesErrorResponse(c, "You must specify the order item id", payload.OrderID)
-		}
-		if item.ProductGroup == "" {
-			return SendSalesErrorResponse(c, "You must specify the item code or group.", payload.OrderID)
-		}
-
-		var product products.Product
-		productErr := initializers.DB.Where("product_code = ?", item.ProductGroup).First(&product).Error
-		if productErr != nil {
-			return SendSalesErrorResponse(c, "The indicated itemcode/group does not exist ().", payload.OrderID)
-		}
-
-		if item.Quantity <= 0 {
-			return SendSalesErrorResponse(c, "The quantity of the product must be greater than zero.", payload.OrderID)
-		}
-
-		seenItemIDs := make(map[string]bool)
-		for _, item := range payload.OrderItems {
-			if seenItemIDs[item.OrderItemID] {
-				return SendSalesErrorResponse(
-					c,
-					fmt.Sprintf("Duplicate order_item_id '%s' found in payload.", item.OrderItemID),
-					payload.OrderID,
-				)
-			}
-			seenItemIDs[item.OrderItemID] = true
-		}
+	if msg := validateSalesOrderPayload(coopId, payload); msg != "" {
+		return SendSalesErrorResponse(c, msg, payload.OrderID)
 	}
 
 	// 4. Map payload → SalesOrder DB model
-	newOrder := sales.SalesOrder{
-		// TempID:                 TempID,
-		CoopID: coopId,
-
-		OrderID:     payload.OrderID,
-		OrderNumber: payload.OrderNumber,
-		ContractID:  payload.ContractID,
-
-		FarmerID:   payload.FarmerID,
-		FarmerName: payload.FarmerName,
-
-		ClubID:   payload.ClubID,
-		ClubName: payload.ClubName,
-
-		FarmerResourceCategory: payload.FarmerResourceCategory,
-		ContractCrop:           payload.ContractCrop,
-		ContractCropVareity:    payload.ContractCropVareity,
-		ContractArea:           payload.ContractArea,
+	newOrder := sales.SalesOrder{CoopID: coopId}
+	payload.Apply(&newOrder)
 
-		SponsorID:   payload.SponsorID,
-		SponsorName: payload.SponsorName,
-
-		BuyerID:   payload.BuyerID,
-		BuyerName: payload.BuyerName,
-
-		PackageSetCaptionPT: payload.PackageSetCaptionPT,
-
-		RegionID:         payload.RegionID,
-		RegionPartID:     payload.RegionPartID,
-		SettlementID:     payload.SettlementID,
-		SettlementPartID: payload.SettlementPartID,
-
-		CustomZone1ID: payload.CustomZone1ID,
-		CustomZone2ID: payload.CustomZone2ID,
-
-		PickupDate: payload.PickupDate,
-		CreatedBy:  payload.CreatedBy,
-
-		NoofOrderItems: len(payload.OrderItems),
-	}
+	// Map order items and price the order from them
+	items := buildSalesOrderItems(newOrder.OrderID, payload.OrderItems, nil)
 
 	// 5. DB transaction (parent + children)
 	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := priceSalesOrder(tx, &newOrder, nil, items); err != nil {
+			return err
+		}
 
 		// Save sales order
 		if err := tx.Create(&newOrder).Error; err != nil {
 			return err
 		}
+		if err := recordSalesOrderStatus(tx, &newOrder, "", "Sales order received"); err != nil {
+			return err
+		}
 		// ctx := context.Background()
 		// q := query.Use(initializers.DB)
-		// Map & save order items
-		if len(payload.OrderItems) > 0 {
-			var items []sales.SalesOrderItem
-
-			for _, item := range payload.OrderItems {
-				// erpItemID , err := GenerateNextOrderItemTempID(ctx, q)
-				// if err != nil {
-				// 	return err
-				// }
-
-				items = append(items, sales.SalesOrderItem{
-					OrderID:              newOrder.OrderID,
-					OrderItemID:          item.OrderItemID,
-					OrderItemNumber:      item.OrderItemNumber,
-					StockKeepingUnit:     item.StockKeepingUnit,
-					ErpItemID:            GenerateNextOrderItemTempID(),
-					ErpItemID2:           GenerateNextOrderItemTempID(),
-					ProductGroup:         item.ProductGroup,
-					InputItemID:          item.InputItemID,
-					InputItemName:        item.InputItemName,
-					InputItemNameCaption: item.InputItemNameCaption,
-					Quantity:             item.Quantity,
-					QuantityUnitKey:      item.QuantityUnitKey,
-					UnitPrice:            item.UnitPrice,
-					Price:                item.Price,
-					PriceUnitKey:         item.PriceUnitKey,
-					NumberOfUnits:        item.NumberOfUnits,
-				})
-			}
-
+		// Save order items
+		if len(items) > 0 {
 			if err := tx.Create(&items).Error; err != nil {
 				return err
 			}
 		}
 
-		return nil
+		// Queue ERP ID + code generation with the order so it cannot be lost
+		return enqueueSalesOrderIDJob(tx, coopId, newOrder.ID, newOrder.OrderID)
 	})
 
 	if err != nil {
@@ -319,19 +235,6 @@ func CreateCustomerSalesOrderHandler(c *fiber.Ctx) error {
 			Message: err.Error(),
 		})
 	}
-	ctx := context.Background()
-	q := query.Use(initializers.DB)
-
-	go func(orderDBID uint) {
-		_, err := GenerateAndSetNextErpSalesOrderIDGen(ctx, q, orderDBID)
-		_, err1 := GenerateAndSetNextErpSalesOrderCodeGen(ctx, q, orderDBID)
-		if err1 != nil {
-			log.Println("❌ ERP SalesOrder Code generation failed:", err1)
-		}
-		if err != nil {
-			log.Println("❌ ERP SalesOrder ID generation failed:", err)
-		}
-	}(newOrder.ID)
 
 	// 6. Response DTO (exactly as you defined)
 	response := sales.CreateSalesOrderResponse{
@@ -350,8 +253,114 @@ func CreateCustomerSalesOrderHandler(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
+// buildSalesOrderItems maps payload items to rows of orderID. Items already
+// on the order (same order_item_id) keep their ERP item IDs.
+func buildSalesOrderItems(orderID string, payload []sales.SalesOrderItem, existing []sales.SalesOrderItem) []sales.SalesOrderItem {
+	erpIDs := make(map[string]sales.SalesOrderItem, len(existing))
+	for _, item := range existing {
+		erpIDs[item.OrderItemID] = item
+	}
+
+	var items []sales.SalesOrderItem
+	for _, item := range payload {
+		erpItemID, erpItemID2 := GenerateNextOrderItemTempID(), GenerateNextOrderItemTempID()
+		if prev, ok := erpIDs[item.OrderItemID]; ok {
+			erpItemID, erpItemID2 = prev.ErpItemID, prev.ErpItemID2
+		}
+
+		items = append(items, sales.SalesOrderItem{
+			OrderID:              orderID,
+			OrderItemID:          item.OrderItemID,
+			OrderItemNumber:      item.OrderItemNumber,
+			StockKeepingUnit:     item.StockKeepingUnit,
+			ErpItemID:            erpItemID,
+			ErpItemID2:           erpItemID2,
+			ProductGroup:         item.ProductGroup,
+			InputItemID:          item.InputItemID,
+			InputItemName:        item.InputItemName,
+			InputItemNameCaption: item.InputItemNameCaption,
+			Quantity:             item.Quantity,
+			QuantityUnitKey:      item.QuantityUnitKey,
+			UnitPrice:            item.UnitPrice,
+			Price:                item.Price,
+			PriceUnitKey:         item.PriceUnitKey,
+			NumberOfUnits:        item.NumberOfUnits,
+		})
+	}
+	return items
+}
+
+// validateSalesOrderPayload checks the farmer, contract and items of a create
+// or update payload and returns the ERP error message, or "" when valid
+func validateSalesOrderPayload(coopId string, payload *sales.CreateSalesOrderSchema) string {
+	var existingFarmer models.FarmerDetails
+
+	if payload.FarmerID == "" {
+		return "You must provide the FarmerID."
+	}
+
+	if payload.ContractID == "" {
+		return "You must provide the ContractID."
+	}
+
+	farmerId := initializers.DB.
+		Where(
+			"farmer_id = ? AND coop_id = ?",
+			payload.FarmerID,
+			coopId,
+		).
+		First(&existingFarmer).
+		Error
+
+	if farmerId != nil {
+		return "The indicated FarmerId does not exist."
+	}
+
+	if !existingFarmer.Active {
+		return "The indicated FarmerId is inactive."
+	}
+
+	for i, item := range payload.OrderItems {
+
+		if item.OrderItemID == "" {
+			return "You must specify the order item id"
+		}
+		if item.ProductGroup == "" {
+			return "You must specify the item code or group."
+		}
+
+		var product products.Product
+		productErr := initializers.DB.Where("product_code = ?", item.ProductGroup).First(&product).Error
+		if productErr != nil {
+			return "The indicated itemcode/group does not exist ()."
+		}
+		if !product.IsActive() {
+			return "The indicated itemcode/group is inactive."
+		}
+		if !product.AvailableIn(coopId) {
+			return "The indicated itemcode/group is not available for the cooperative."
+		}
+
+		if item.Quantity <= 0 {
+			return "The quantity of the product must be greater than zero."
+		}
+
+		if i == 0 {
+			seenItemIDs := make(map[string]bool)
+			for _, item := range payload.OrderItems {
+				if seenItemIDs[item.OrderItemID] {
+					return fmt.Sprintf("Duplicate order_item_id '%s' found in payload.", item.OrderItemID)
+				}
+				seenItemIDs[item.OrderItemID] = true
+			}
+		}
+	}
+
+	return ""
+}
+
 func SendSalesErrorResponse(c *fiber.Ctx, message string, orderId string) error {
-	now := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	now := clock.Now().UTC().Format("2006-01-02T15:04:05.000Z")
 	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 		"success": false,
 		"data": fiber.Map{
@@ -375,6 +384,7 @@ func SendSalesErrorResponse(c *fiber.Ctx, message string, orderId string) error
 // @Param        coopId path      string  true   " "
 // @Param        updatedFrom   query     string  false  " "
 // @Param        updatedTo     query     string  false  " "
+// @Param        status        query     string  false  "CONFIRMED, PARTIALLY_DELIVERED, DELIVERED, INVOICED or CANCELLED"
 // @Param        page          query     int     false  "Page number"    default(1)
 // @Param        perPage         query     int     false  "Items per page" default(10)
 // @Success      200    {object}  sales.ListSalesOrderResponse
@@ -398,9 +408,20 @@ func GetCustomerSalesDetailHandler(c *fiber.Ctx) error {
 	offset := (page - 1) * perPage
 	var totalRecords int64
 
+	// Orders are listed once the ERP has confirmed them, which an order
+	// cancelled while RECEIVED never was
 	query := initializers.DB.
 		Model(&sales.SalesOrder{}).
-		Where("coop_id = ? AND ((erp_sales_order_id IS NOT NULL  AND erp_sales_order_id != '')OR (erp_sales_order_code IS NOT NULL AND erp_sales_order_code != ''))", coopId)
+		Where("coop_id = ? AND status <> ? AND ((erp_sales_order_id IS NOT NULL  AND erp_sales_order_id != '')OR (erp_sales_order_code IS NOT NULL AND erp_sales_order_code != ''))", coopId, sales.StatusReceived)
+
+	if status := c.Query("status"); status != "" {
+		if !sales.ValidStatus(status) || status == sales.StatusReceived {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"Message": "Invalid status. Use CONFIRMED, PARTIALLY_DELIVERED, DELIVERED, INVOICED or CANCELLED",
+			})
+		}
+		query = query.Where("status = ?", status)
+	}
 
 	if updatedFrom != "" && updatedTo != "" {
 		fromTime, err := time.Parse(time.RFC3339, updatedFrom)
@@ -442,6 +463,7 @@ func GetCustomerSalesDetailHandler(c *fiber.Ctx) error {
 			ErpSalesOrderId:     f.ErpSalesOrderId,
 			ErpSalesOrderCode:   f.ErpSalesOrderCode,
 			SpicSalesOrderId:    f.OrderID,
+			Status:              f.Status,
 			CreatedAt:           f.CreatedAt.Format("2006-01-02T15:04:05Z"),
 			UpdatedAt:           f.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 		})
@@ -493,11 +515,13 @@ func GetCustomerSalesOrderDetailsHandler(c *fiber.Ctx) error {
 		ErpSalesOrderId:     salesOrder.ErpSalesOrderId,
 		ErpSalesOrderCode:   salesOrder.ErpSalesOrderCode,
 		SpicSalesOrderId:    salesOrder.OrderID,
-		CreatedAt:           time.Now().UTC().Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:           time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		CreatedAt:           clock.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:           clock.Now().UTC().Format("2006-01-02T15:04:05Z"),
 		OrderValue:          salesOrder.OrderValue,
 		TaxAmount:           salesOrder.TaxAmount,
 		TotalAmount:         salesOrder.TotalAmount,
+		Currency:            salesOrder.Currency,
+		Status:              salesOrder.Status,
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response)
@@ -518,3 +542,356 @@ func SendOrderIdErrorResponse(c *fiber.Ctx, msg string, orderId string) error {
 	})
 
 }
+
+func sendSalesConflictResponse(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusConflict).JSON(sales.ErrorSalesOrderResponse{
+		Success: false,
+		Message: message,
+	})
+}
+
+// findAmendableSalesOrder loads the order of the path and checks it can still
+// change: not cancelled and without delivery documents. When it can't it
+// writes the error response and returns false.
+func findAmendableSalesOrder(c *fiber.Ctx, order *sales.SalesOrder) (bool, error) {
+	coopId := c.Params("coopId")
+	orderId := c.Params("orderId")
+
+	if !isCoopAllowed(coopId) {
+		return false, SendSalesErrorResponse(c, "The indicated cooperative does not exist.", orderId)
+	}
+
+	var found sales.SalesOrder
+	if err := initializers.DB.Where("coop_id = ? AND order_id = ?", coopId, orderId).First(&found).Error; err != nil {
+		return false, c.Status(fiber.StatusNotFound).JSON(sales.ErrorSalesOrderResponse{
+			Success: false,
+			Message: "There is no order with the indicated OrderID.",
+		})
+	}
+
+	if msg := salesOrderAmendConflict(initializers.DB, &found); msg != "" {
+		return false, sendSalesConflictResponse(c, msg)
+	}
+
+	*order = found
+	return true, nil
+}
+
+// salesOrderConflict carries the message of a 409 detected inside a transaction
+type salesOrderConflict string
+
+func (e salesOrderConflict) Error() string {
+	return string(e)
+}
+
+// salesOrderAmendConflict returns why order can no longer be amended, or ""
+func salesOrderAmendConflict(db *gorm.DB, order *sales.SalesOrder) string {
+	if order.Status == sales.StatusCancelled {
+		return "The sales order has been cancelled."
+	}
+
+	var documents int64
+	db.Model(&delivery.CreateDeliveryDocuments{}).
+		Where("coop_id = ? AND order_id = ? AND status <> ?", order.CoopID, order.OrderID, StatusVoided).
+		Count(&documents)
+	if documents > 0 {
+		return "Delivery documents already exist for the order, it can no longer be amended."
+	}
+	return ""
+}
+
+// lockSalesOrder reads the order with a row lock, so amendments and
+// delivery documents of the same order run one after the other
+func lockSalesOrder(tx *gorm.DB, coopId, orderId string, order *sales.SalesOrder) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("coop_id = ? AND order_id = ?", coopId, orderId).
+		First(order).
+		Error
+}
+
+// saveAmendedSalesOrder writes the amendable columns of order. The ERP IDs
+// and their timestamp belong to the ID job and the status to the lifecycle,
+// so values read before the transaction never overwrite theirs.
+func saveAmendedSalesOrder(tx *gorm.DB, order *sales.SalesOrder) error {
+	return tx.
+		Omit("OrderItems", "ErpSalesOrderId", "ErpSalesOrderCode", "IdUpdatedAt",
+			"Status", "CancelledAt", "CancellationReason").
+		Save(order).
+		Error
+}
+
+// amendSalesOrder replaces order and its items with payload in one
+// transaction, repricing the order when its items change
+func amendSalesOrder(c *fiber.Ctx, order *sales.SalesOrder, oldItems []sales.SalesOrderItem, payload *sales.CreateSalesOrderSchema) error {
+	coopId := c.Params("coopId")
+
+	if payload.OrderID != "" && payload.OrderID != order.OrderID {
+		return SendSalesErrorResponse(c, "The OrderID of an order cannot be changed.", order.OrderID)
+	}
+	payload.OrderID = order.OrderID
+
+	if msg := validateSalesOrderPayload(coopId, payload); msg != "" {
+		return SendSalesErrorResponse(c, msg, order.OrderID)
+	}
+
+	items := buildSalesOrderItems(order.OrderID, payload.OrderItems, oldItems)
+	payload.Apply(order)
+	now := clock.Now()
+	order.UpdatedAt = &now
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// The order may have been cancelled or delivered since it was read
+		var current sales.SalesOrder
+		if err := lockSalesOrder(tx, coopId, order.OrderID, &current); err != nil {
+			return err
+		}
+		if msg := salesOrderAmendConflict(tx, &current); msg != "" {
+			return salesOrderConflict(msg)
+		}
+
+		if err := priceSalesOrder(tx, order, oldItems, items); err != nil {
+			return err
+		}
+		if err := saveAmendedSalesOrder(tx, order); err != nil {
+			return err
+		}
+		if err := tx.Where("order_id = ?", order.OrderID).Delete(&sales.SalesOrderItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			return tx.Create(&items).Error
+		}
+		return nil
+	})
+	var conflict salesOrderConflict
+	if errors.As(err, &conflict) {
+		return sendSalesConflictResponse(c, string(conflict))
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(sales.ErrorSalesOrderResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(sales.CreateSalesOrderResponse{
+		Success: true,
+		Data: sales.CreateSalesOrderResponseData{
+			TempERPSalesOrderId: order.TempID,
+			ErpSalesOrderId:     order.ErpSalesOrderId,
+			ErpSalesOrderCode:   order.ErpSalesOrderCode,
+			SpicSalesOrderId:    order.OrderID,
+			CreatedAt:           order.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt:           order.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			Message:             "Document updated with success.",
+		},
+	})
+}
+
+// UpdateCustomerSalesOrderHandler handles PUT /spic_to_erp/customers/:coopId/salesorders/:orderId
+// @Summary      Replace a sales order
+// @Description  Replace the order fields and items and recalculate the totals. Rejected (409) once the order is cancelled or has delivery documents.
+// @Tags         salesoreder
+// @Accept       json
+// @Produce      json
+// @Param        coopId   path      string                        true  "Cooperative ID"
+// @Param        orderId  path      string                        true  "Order ID"
+// @Param        detail   body      sales.CreateSalesOrderSchema  true  "Full order payload"
+// @Success      200      {object}  sales.CreateSalesOrderResponse
+// @Router       /spic_to_erp/customers/{coopId}/salesorders/{orderId} [put]
+func UpdateCustomerSalesOrderHandler(c *fiber.Ctx) error {
+	var payload *sales.CreateSalesOrderSchema
+	if err := c.BodyParser(&payload); err != nil || payload == nil {
+		message := "The request body must be a sales order."
+		if err != nil {
+			message = err.Error()
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(sales.ErrorSalesOrderResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
+	var order sales.SalesOrder
+	if found, err := findAmendableSalesOrder(c, &order); !found {
+		return err
+	}
+
+	var oldItems []sales.SalesOrderItem
+	initializers.DB.Where("order_id = ?", order.OrderID).Find(&oldItems)
+
+	return amendSalesOrder(c, &order, oldItems, payload)
+}
+
+// PatchCustomerSalesOrderHandler handles PATCH /spic_to_erp/customers/:coopId/salesorders/:orderId
+// @Summary      Amend a sales order
+// @Description  Merge the given fields into the order. order_items are merged by order_item_id: known items are updated, new ones added, and {"order_item_id": "...", "remove": true} removes one. Totals are recalculated. Rejected (409) once the order is cancelled or has delivery documents.
+// @Tags         salesoreder
+// @Accept       json
+// @Produce      json
+// @Param        coopId   path      string                  true  "Cooperative ID"
+// @Param        orderId  path      string                  true  "Order ID"
+// @Param        detail   body      map[string]interface{}  true  "Fields to change"
+// @Success      200      {object}  sales.CreateSalesOrderResponse
+// @Router       /spic_to_erp/customers/{coopId}/salesorders/{orderId} [patch]
+func PatchCustomerSalesOrderHandler(c *fiber.Ctx) error {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(c.Body(), &patch); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(sales.ErrorSalesOrderResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	var order sales.SalesOrder
+	if found, err := findAmendableSalesOrder(c, &order); !found {
+		return err
+	}
+
+	var oldItems []sales.SalesOrderItem
+	initializers.DB.Where("order_id = ?", order.OrderID).Find(&oldItems)
+
+	payload, err := mergeSalesOrderPatch(sales.NewSalesOrderSchema(&order, oldItems), patch)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(sales.ErrorSalesOrderResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	return amendSalesOrder(c, &order, oldItems, payload)
+}
+
+// mergeSalesOrderPatch applies a JSON merge patch to current. Fields replace
+// the current values; order_items are merged item by item on order_item_id.
+func mergeSalesOrderPatch(current sales.CreateSalesOrderSchema, patch map[string]json.RawMessage) (*sales.CreateSalesOrderSchema, error) {
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &merged); err != nil {
+		return nil, err
+	}
+
+	for field, value := range patch {
+		if field != "order_items" {
+			merged[field] = value
+			continue
+		}
+
+		var itemPatches []map[string]json.RawMessage
+		if err := json.Unmarshal(value, &itemPatches); err != nil {
+			return nil, fmt.Errorf("order_items must be an array of items")
+		}
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(merged["order_items"], &items); err != nil {
+			return nil, err
+		}
+
+		for _, itemPatch := range itemPatches {
+			var id string
+			if err := json.Unmarshal(itemPatch["order_item_id"], &id); err != nil || id == "" {
+				return nil, fmt.Errorf("every order_items entry needs an order_item_id")
+			}
+			var remove bool
+			json.Unmarshal(itemPatch["remove"], &remove)
+			delete(itemPatch, "remove")
+
+			idx := -1
+			for i, item := range items {
+				var itemID string
+				json.Unmarshal(item["order_item_id"], &itemID)
+				if itemID == id {
+					idx = i
+					break
+				}
+			}
+
+			switch {
+			case remove && idx >= 0:
+				items = append(items[:idx], items[idx+1:]...)
+			case remove:
+				return nil, fmt.Errorf("order_item_id '%s' is not on the order", id)
+			case idx >= 0:
+				for k, v := range itemPatch {
+					items[idx][k] = v
+				}
+			default:
+				items = append(items, itemPatch)
+			}
+		}
+
+		if merged["order_items"], err = json.Marshal(items); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err = json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var payload sales.CreateSalesOrderSchema
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// CancelCustomerSalesOrderHandler handles POST /spic_to_erp/customers/:coopId/salesorders/:orderId/cancel
+// @Summary      Cancel a sales order
+// @Description  Mark the order CANCELLED. Rejected (409) when it is already cancelled, past CONFIRMED or has delivery documents.
+// @Tags         salesoreder
+// @Accept       json
+// @Produce      json
+// @Param        coopId   path      string                        true   "Cooperative ID"
+// @Param        orderId  path      string                        true   "Order ID"
+// @Param        detail   body      sales.CancelSalesOrderSchema  false  "Cancellation reason"
+// @Success      200      {object}  sales.CreateSalesOrderResponse
+// @Router       /spic_to_erp/customers/{coopId}/salesorders/{orderId}/cancel [post]
+func CancelCustomerSalesOrderHandler(c *fiber.Ctx) error {
+	var payload sales.CancelSalesOrderSchema
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&payload); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(sales.ErrorSalesOrderResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+	}
+
+	var order sales.SalesOrder
+	if found, err := findAmendableSalesOrder(c, &order); !found {
+		return err
+	}
+
+	reason := "Sales order cancelled"
+	if payload.Reason != "" {
+		reason += ": " + payload.Reason
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := transitionSalesOrder(tx, &order, sales.StatusCancelled, reason); err != nil {
+			return err
+		}
+		order.CancelledAt = order.UpdatedAt
+		order.CancellationReason = payload.Reason
+		return tx.Model(&order).Select("CancelledAt", "CancellationReason").Updates(&order).Error
+	})
+	if err != nil {
+		return sendSalesOrderStatusConflict(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(sales.CreateSalesOrderResponse{
+		Success: true,
+		Data: sales.CreateSalesOrderResponseData{
+			TempERPSalesOrderId: order.TempID,
+			ErpSalesOrderId:     order.ErpSalesOrderId,
+			ErpSalesOrderCode:   order.ErpSalesOrderCode,
+			SpicSalesOrderId:    order.OrderID,
+			CreatedAt:           order.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt:           order.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			Message:             "Document cancelled with success.",
+		},
+	})
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"gorm.io/gorm"
+)
+
+// errSalesOrderStatusChanged means the order left the status it was read in
+// before a transition could be applied
+var errSalesOrderStatusChanged = errors.New("sales order status changed")
+
+// recordSalesOrderStatus adds an entry to the status history of the order
+func recordSalesOrderStatus(tx *gorm.DB, order *sales.SalesOrder, from, reason string) error {
+	return tx.Create(&sales.SalesOrderStatusChange{
+		CoopID:     order.CoopID,
+		OrderID:    order.OrderID,
+		FromStatus: from,
+		ToStatus:   order.Status,
+		Reason:     reason,
+	}).Error
+}
+
+// transitionSalesOrder moves the order to status and records the change.
+// The update only applies while the order is still in the status it was
+// read in, so concurrent transitions cannot both succeed.
+func transitionSalesOrder(tx *gorm.DB, order *sales.SalesOrder, status, reason string) error {
+	if err := sales.ValidateTransition(order.Status, status); err != nil {
+		return err
+	}
+
+	now := clock.Now()
+	res := tx.Model(&sales.SalesOrder{}).
+		Where("id = ? AND status = ?", order.ID, order.Status).
+		Updates(map[string]interface{}{"status": status, "updated_at": now})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errSalesOrderStatusChanged
+	}
+
+	from := order.Status
+	order.Status = status
+	order.UpdatedAt = &now
+	return recordSalesOrderStatus(tx, order, from, reason)
+}
+
+// syncSalesOrderDeliveryStatus moves a confirmed order to the status its
+// delivered quantities put it in, after delivery documents are created or voided
+func syncSalesOrderDeliveryStatus(tx *gorm.DB, coopId, orderId, reason string) error {
+	var order sales.SalesOrder
+	if err := tx.Where("coop_id = ? AND order_id = ?", coopId, orderId).First(&order).Error; err != nil {
+		return err
+	}
+	var items []sales.SalesOrderItem
+	if err := tx.Where("order_id = ?", orderId).Find(&items).Error; err != nil {
+		return err
+	}
+
+	status := sales.DeliveryStatus(items)
+	if status == order.Status {
+		return nil
+	}
+	return transitionSalesOrder(tx, &order, status, reason)
+}
+
+// markSalesOrderInvoiced moves a delivered order to INVOICED once every
+// delivery document that was not voided has an issued invoice
+func markSalesOrderInvoiced(tx *gorm.DB, coopId, orderId string) error {
+	var order sales.SalesOrder
+	if err := tx.Where("coop_id = ? AND order_id = ?", coopId, orderId).First(&order).Error; err != nil {
+		return err
+	}
+	if order.Status != sales.StatusDelivered || !initializers.SalesOrderInvoiced(tx, coopId, orderId) {
+		return nil
+	}
+	return transitionSalesOrder(tx, &order, sales.StatusInvoiced, "All delivery documents invoiced")
+}
+
+// sendSalesOrderStatusConflict answers a request whose status transition
+// was rejected or lost a race
+func sendSalesOrderStatusConflict(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sales.ErrInvalidTransition):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"Message": "The sales order cannot change status: " + err.Error(),
+		})
+	case errors.Is(err, errSalesOrderStatusChanged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"Message": "The sales order was changed by another request, please retry",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"Message": err.Error(),
+	})
+}
+
+// GetSalesOrderStatusHistoryHandler handles GET /spic_to_erp/customers/:coopId/salesorders/:orderId/history
+// @Summary      Get the status history of a sales order
+// @Description  Lists the status transitions of the order, oldest first
+// @Tags         salesoreder
+// @Produce      json
+// @Param        coopId path      string  true   " "
+// @Param        orderId path      string  true   " "
+// @Success      200    {object}  sales.SalesOrderStatusHistoryResponse
+// @Router       /spic_to_erp/customers/{coopId}/salesorders/{orderId}/history [get]
+func GetSalesOrderStatusHistoryHandler(c *fiber.Ctx) error {
+	coopId := c.Params("coopId")
+	orderId := c.Params("orderId")
+
+	if !isCoopAllowed(coopId) {
+		return SendOrderIdErrorResponse(c, "The indicated cooperative does not exist.", orderId)
+	}
+
+	var order sales.SalesOrder
+	if err := initializers.DB.Where("coop_id = ? AND order_id = ?", coopId, orderId).First(&order).Error; err != nil {
+		return SendOrderIdErrorResponse(c, "There is no order with the indicated OrderID.", orderId)
+	}
+
+	history := make([]sales.SalesOrderStatusChange, 0)
+	if err := initializers.DB.Where("coop_id = ? AND order_id = ?", coopId, orderId).Order("id").Find(&history).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"Message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(sales.SalesOrderStatusHistoryResponse{
+		SpicSalesOrderId: order.OrderID,
+		Status:           order.Status,
+		History:          history,
+	})
+}
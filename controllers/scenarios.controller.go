@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/scenarios"
+	"gorm.io/gorm"
+)
+
+type SetScenarioStateSchema struct {
+	State string `json:"state" example:"Started"`
+}
+
+// findScenario loads the scenario of the path. When there is none it writes
+// the error response and returns false.
+func findScenario(c *fiber.Ctx, sc *scenarios.Scenario) (bool, error) {
+	err := initializers.DB.Where("name = ?", c.Params("name")).First(sc).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Scenario not found",
+		})
+	}
+	if err != nil {
+		return false, c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	return true, nil
+}
+
+// ListScenariosHandler handles GET /admin/scenarios
+// @Summary      List scenarios with their current state
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/scenarios [get]
+func ListScenariosHandler(c *fiber.Ctx) error {
+	var list []scenarios.Scenario
+	if err := initializers.DB.Order("id").Find(&list).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// CreateScenarioHandler handles POST /admin/scenarios
+// @Summary      Create a scenario
+// @Description  Steps fire on matching /spic_to_erp requests in a given state, move the scenario to the next state and optionally override the response
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        scenario  body      scenarios.Scenario  true  "Scenario"
+// @Success      201       {object}  map[string]interface{}
+// @Router       /admin/scenarios [post]
+func CreateScenarioHandler(c *fiber.Ctx) error {
+	var payload scenarios.Scenario
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	payload.ID = 0
+	if err := payload.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+	payload.State = payload.InitialState
+
+	var count int64
+	initializers.DB.Model(&scenarios.Scenario{}).Where("name = ?", payload.Name).Count(&count)
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Scenario " + payload.Name + " already exists",
+		})
+	}
+
+	if err := initializers.DB.Create(&payload).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    payload,
+	})
+}
+
+// GetScenarioHandler handles GET /admin/scenarios/:name
+// @Summary      Get a scenario
+// @Tags         admin
+// @Produce      json
+// @Param        name  path  string  true  "Scenario name"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/scenarios/{name} [get]
+func GetScenarioHandler(c *fiber.Ctx) error {
+	var sc scenarios.Scenario
+	if found, err := findScenario(c, &sc); !found {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    sc,
+	})
+}
+
+// UpdateScenarioHandler handles PUT /admin/scenarios/:name
+// @Summary      Replace a scenario's steps
+// @Description  The scenario is reset to its initial state
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        name      path      string              true  "Scenario name"
+// @Param        scenario  body      scenarios.Scenario  true  "Scenario"
+// @Success      200       {object}  map[string]interface{}
+// @Router       /admin/scenarios/{name} [put]
+func UpdateScenarioHandler(c *fiber.Ctx) error {
+	var existing scenarios.Scenario
+	if found, err := findScenario(c, &existing); !found {
+		return err
+	}
+
+	var payload scenarios.Scenario
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	payload.ID = existing.ID
+	payload.Name = existing.Name
+	payload.CreatedAt = existing.CreatedAt
+	if err := payload.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+	payload.State = payload.InitialState
+
+	if err := initializers.DB.Save(&payload).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    payload,
+	})
+}
+
+// SetScenarioStateHandler handles PUT /admin/scenarios/:name/state
+// @Summary      Force a scenario into a state
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string                  true  "Scenario name"
+// @Param        body  body      SetScenarioStateSchema  true  "State"
+// @Success      200   {object}  map[string]interface{}
+// @Router       /admin/scenarios/{name}/state [put]
+func SetScenarioStateHandler(c *fiber.Ctx) error {
+	var sc scenarios.Scenario
+	if found, err := findScenario(c, &sc); !found {
+		return err
+	}
+
+	var payload SetScenarioStateSchema
+	if err := c.BodyParser(&payload); err != nil || payload.State == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "state is required",
+		})
+	}
+
+	if err := initializers.DB.Model(&sc).Update("state", payload.State).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    sc,
+	})
+}
+
+// ResetScenarioHandler handles POST /admin/scenarios/:name/reset
+// @Summary      Put a scenario back into its initial state
+// @Tags         admin
+// @Produce      json
+// @Param        name  path  string  true  "Scenario name"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/scenarios/{name}/reset [post]
+func ResetScenarioHandler(c *fiber.Ctx) error {
+	var sc scenarios.Scenario
+	if found, err := findScenario(c, &sc); !found {
+		return err
+	}
+
+	if err := initializers.DB.Model(&sc).Update("state", sc.InitialState).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    sc,
+	})
+}
+
+// ResetAllScenariosHandler handles POST /admin/scenarios/reset
+// @Summary      Put every scenario back into its initial state
+// @Tags         admin
+// @Success      204
+// @Router       /admin/scenarios/reset [post]
+func ResetAllScenariosHandler(c *fiber.Ctx) error {
+	if err := initializers.DB.Model(&scenarios.Scenario{}).
+		Where("1 = 1").
+		Update("state", gorm.Expr("initial_state")).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// DeleteScenarioHandler handles DELETE /admin/scenarios/:name
+// @Summary      Delete a scenario
+// @Tags         admin
+// @Param        name  path  string  true  "Scenario name"
+// @Success      204
+// @Router       /admin/scenarios/{name} [delete]
+func DeleteScenarioHandler(c *fiber.Ctx) error {
+	res := initializers.DB.Where("name = ?", c.Params("name")).Delete(&scenarios.Scenario{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": res.Error.Error(),
+		})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Scenario not found",
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/stubs"
+	"gorm.io/gorm"
+)
+
+// StubHandler answers requests that no built-in route matched from the
+// stubs table (highest priority first) and falls through to the 404 otherwise.
+func StubHandler(c *fiber.Ctx) error {
+	var list []stubs.Stub
+	if err := initializers.DB.Order("priority DESC, id").Find(&list).Error; err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return c.Next()
+	}
+
+	req := stubRequest(c)
+	method, path := c.Method(), c.Path()
+
+	for i := range list {
+		stub := &list[i]
+		if !stub.Match(method, path, req) {
+			continue
+		}
+
+		body, err := stub.Render(req)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Stub " + strconv.Itoa(int(stub.ID)) + " template failed: " + err.Error(),
+			})
+		}
+
+		initializers.DB.Model(stub).UpdateColumn("hits", gorm.Expr("hits + 1"))
+
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		for k, v := range stub.ResponseHeaders {
+			c.Set(k, v)
+		}
+		return c.Status(stub.ResponseStatus).SendString(body)
+	}
+
+	return c.Next()
+}
+
+func stubRequest(c *fiber.Ctx) *stubs.Request {
+	query := map[string]string{}
+	c.Request().URI().QueryArgs().VisitAll(func(k, v []byte) {
+		query[string(k)] = string(v)
+	})
+	headers := map[string]string{}
+	c.Request().Header.VisitAll(func(k, v []byte) {
+		headers[string(k)] = string(v)
+	})
+
+	return stubs.NewRequest(utils.CopyString(c.Method()), c.OriginalURL(), query, headers, c.Body())
+}
+
+func parseStubID(c *fiber.Ctx) uint {
+	id, _ := strconv.ParseUint(c.Params("stubId"), 10, 64)
+	return uint(id)
+}
+
+func stubNotFound(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"status":  "fail",
+		"message": "Stub not found",
+	})
+}
+
+// ListStubsHandler handles GET /admin/stubs
+// @Summary      List runtime stubs
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/stubs [get]
+func ListStubsHandler(c *fiber.Ctx) error {
+	var list []stubs.Stub
+	if err := initializers.DB.Order("priority DESC, id").Find(&list).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// CreateStubHandler handles POST /admin/stubs
+// @Summary      Register a runtime stub
+// @Description  Answers requests that no built-in route handles when method, path template, headers and body conditions match
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        stub  body      stubs.Stub  true  "Stub definition"
+// @Success      201   {object}  map[string]interface{}
+// @Router       /admin/stubs [post]
+func CreateStubHandler(c *fiber.Ctx) error {
+	var payload stubs.Stub
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	payload.ID = 0
+	payload.Hits = 0
+	if err := payload.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if err := initializers.DB.Create(&payload).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    payload,
+	})
+}
+
+// GetStubHandler handles GET /admin/stubs/:stubId
+// @Summary      Get a runtime stub
+// @Tags         admin
+// @Produce      json
+// @Param        stubId  path  int  true  "Stub ID"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /admin/stubs/{stubId} [get]
+func GetStubHandler(c *fiber.Ctx) error {
+	var stub stubs.Stub
+	if err := initializers.DB.First(&stub, parseStubID(c)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return stubNotFound(c)
+		}
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    stub,
+	})
+}
+
+// UpdateStubHandler handles PUT /admin/stubs/:stubId
+// @Summary      Replace a runtime stub
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        stubId  path      int         true  "Stub ID"
+// @Param        stub    body      stubs.Stub  true  "Stub definition"
+// @Success      200     {object}  map[string]interface{}
+// @Router       /admin/stubs/{stubId} [put]
+func UpdateStubHandler(c *fiber.Ctx) error {
+	var existing stubs.Stub
+	if err := initializers.DB.First(&existing, parseStubID(c)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return stubNotFound(c)
+		}
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	var payload stubs.Stub
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	payload.ID = existing.ID
+	payload.Hits = existing.Hits
+	payload.CreatedAt = existing.CreatedAt
+	if err := payload.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "fail",
+			"message": err.Error(),
+		})
+	}
+
+	if err := initializers.DB.Save(&payload).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    payload,
+	})
+}
+
+// DeleteStubHandler handles DELETE /admin/stubs/:stubId
+// @Summary      Remove a runtime stub
+// @Tags         admin
+// @Param        stubId  path  int  true  "Stub ID"
+// @Success      204
+// @Router       /admin/stubs/{stubId} [delete]
+func DeleteStubHandler(c *fiber.Ctx) error {
+	res := initializers.DB.Delete(&stubs.Stub{}, parseStubID(c))
+	if res.Error != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": res.Error.Error(),
+		})
+	}
+	if res.RowsAffected == 0 {
+		return stubNotFound(c)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ClearStubsHandler handles DELETE /admin/stubs
+// @Summary      Remove all runtime stubs
+// @Tags         admin
+// @Success      204
+// @Router       /admin/stubs [delete]
+func ClearStubsHandler(c *fiber.Ctx) error {
+	if err := initializers.DB.Where("1 = 1").Delete(&stubs.Stub{}).Error; err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package initializers
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shyamsundaar/karino-mock-server/models/cooperatives"
+	"gorm.io/gorm"
+)
+
+// coopCache mirrors the cooperatives table so the per-request coop check
+// doesn't hit the database. Admin writes refresh it via ReloadCooperatives.
+var (
+	coopCacheMu sync.RWMutex
+	coopCache   = map[string]cooperatives.Cooperative{}
+)
+
+// SeedCooperatives creates a row for every coop in ALLOWED_COOPERATIVES that
+// isn't in the table yet. Existing rows are left alone, so a coop disabled
+// through the admin API stays disabled across restarts.
+func SeedCooperatives(db *gorm.DB, allowed string) {
+	for _, id := range strings.Split(allowed, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+
+		coop := cooperatives.Cooperative{CoopID: id, Name: id, Enabled: true}
+		res := db.Where(cooperatives.Cooperative{CoopID: id}).FirstOrCreate(&coop)
+		if res.Error != nil {
+			log.Fatalf("❌ Failed to seed cooperative %s: %v", id, res.Error)
+		}
+		if res.RowsAffected > 0 {
+			log.Printf("✅ Cooperative %s seeded from ALLOWED_COOPERATIVES", id)
+		}
+	}
+
+	if err := ReloadCooperatives(db); err != nil {
+		log.Fatalf("❌ Failed to load cooperatives: %v", err)
+	}
+}
+
+// ReloadCooperatives replaces the cache with the current table contents
+func ReloadCooperatives(db *gorm.DB) error {
+	var list []cooperatives.Cooperative
+	if err := db.Find(&list).Error; err != nil {
+		return err
+	}
+
+	cache := make(map[string]cooperatives.Cooperative, len(list))
+	for _, c := range list {
+		cache[c.CoopID] = c
+	}
+
+	coopCacheMu.Lock()
+	coopCache = cache
+	coopCacheMu.Unlock()
+	return nil
+}
+
+// LookupCooperative returns the cached cooperative, enabled or not
+func LookupCooperative(coopId string) (cooperatives.Cooperative, bool) {
+	coopCacheMu.RLock()
+	defer coopCacheMu.RUnlock()
+	c, ok := coopCache[coopId]
+	return c, ok
+}
+
+// CooperativeIDs lists the cached cooperatives, enabled or not
+func CooperativeIDs() []string {
+	coopCacheMu.RLock()
+	defer coopCacheMu.RUnlock()
+	ids := make([]string, 0, len(coopCache))
+	for id := range coopCache {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// CoopEnabled reports whether coopId is a known, enabled cooperative
+func CoopEnabled(coopId string) bool {
+	c, ok := LookupCooperative(coopId)
+	return ok && c.Enabled
+}
@@ -1,45 +1,139 @@
 package initializers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/models/apikeys"
+	"github.com/shyamsundaar/karino-mock-server/models/cooperatives"
 	"github.com/shyamsundaar/karino-mock-server/models/delivery"
 	"github.com/shyamsundaar/karino-mock-server/models/deliveryproof"
 	models "github.com/shyamsundaar/karino-mock-server/models/farmers"
+	"github.com/shyamsundaar/karino-mock-server/models/invoices"
+	"github.com/shyamsundaar/karino-mock-server/models/jobs"
 	"github.com/shyamsundaar/karino-mock-server/models/products"
 	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"github.com/shyamsundaar/karino-mock-server/models/scenarios"
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
+	"github.com/shyamsundaar/karino-mock-server/models/stubs"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
+// Supported values for DB_DRIVER
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
+)
+
+// openDialector picks the gorm dialector for the configured storage driver.
+// An empty DB_DRIVER keeps the old behaviour (MySQL from docker-compose).
+func openDialector(config *Config) (gorm.Dialector, error) {
+	switch strings.ToLower(strings.TrimSpace(config.DBDriver)) {
+	case "", DriverMySQL:
+		// dsn := fmt.Sprintf("user:pass@tcp(127.0.0.1:3306)/dbname?charset=utf8mb4&parseTime=True&loc=UTC")
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC", config.DBUserName, config.DBUserPassword, config.DBHost, config.DBPort, config.DBName)
+		return mysql.Open(dsn), nil
+
+	case DriverSQLite:
+		path := config.SQLitePath
+		if path == "" {
+			path = "karino.db"
+		}
+		return sqlite.Open(fmt.Sprintf("file:%s?_busy_timeout=5000&_foreign_keys=on", path)), nil
+
+	case DriverMemory:
+		// Shared cache keeps one database alive across the pool's connections
+		return sqlite.Open("file:karino?mode=memory&cache=shared&_busy_timeout=5000"), nil
+	}
+
+	return nil, fmt.Errorf("unsupported DB_DRIVER %q (use mysql, sqlite or memory)", config.DBDriver)
+}
+
+// IsDuplicateKey reports whether err is a unique constraint violation of
+// the configured driver
+func IsDuplicateKey(err error) bool {
+	if err == nil {
+		return false
+	}
+	if translator, ok := DB.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// dbLogLevel maps DB_LOG_LEVEL to GORM's levels; SQL is logged by default
+func dbLogLevel(level string) logger.LogLevel {
+	switch strings.ToLower(level) {
+	case "silent":
+		return logger.Silent
+	case "error":
+		return logger.Error
+	case "warn":
+		return logger.Warn
+	}
+	return logger.Info
+}
+
 func ConnectDB(config *Config) {
-	var err error
-	// dsn := fmt.Sprintf("user:pass@tcp(127.0.0.1:3306)/dbname?charset=utf8mb4&parseTime=True&loc=UTC")
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC", config.DBUserName, config.DBUserPassword, config.DBHost, config.DBPort, config.DBName)
+	dialector, err := openDialector(config)
+	if err != nil {
+		log.Fatal("Failed to connect to the Database! \n", err.Error())
+		os.Exit(1)
+	}
 
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// NowFunc makes CreatedAt/UpdatedAt follow the virtual clock
+	DB, err = gorm.Open(dialector, &gorm.Config{NowFunc: clock.Now})
 	if err != nil {
 		log.Fatal("Failed to connect to the Database! \n", err.Error())
 		os.Exit(1)
 	}
 
-	DB.Logger = logger.Default.LogMode(logger.Info)
+	if dialector.Name() == "sqlite" {
+		// SQLite allows a single writer; serialise access instead of failing with SQLITE_BUSY
+		sqlDB, err := DB.DB()
+		if err != nil {
+			log.Fatal("Failed to connect to the Database! \n", err.Error())
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	DB.Logger = logger.Default.LogMode(dbLogLevel(config.DBLogLevel))
 
 	log.Println("Running Migrations")
+	NormalizeGeneratedCodes(DB)
+	MigrateWaybills(DB)
 	err = DB.AutoMigrate(&models.FarmerDetails{}, &sales.SalesOrder{}, &sales.SalesOrderItem{}, &products.Product{},
 		&delivery.CreateDeliveryDocuments{},
-		&deliveryproof.Waybill{}, &deliveryproof.WaybillItem{})
+		&deliveryproof.Waybill{}, &deliveryproof.WaybillItem{},
+		&sequences.Sequence{}, &jobs.Job{}, &stubs.Stub{},
+		&scenarios.Scenario{}, &cooperatives.Cooperative{},
+		&apikeys.APIKey{}, &products.TaxRule{},
+		&invoices.Invoice{}, &invoices.InvoiceLine{},
+		&sales.SalesOrderStatusChange{})
+	BackfillDeliveries(DB)
+	BackfillWaybillPhotos(DB)
+	BackfillSalesOrderStatuses(DB)
 	SeedInitialData(DB)
+	SeedCooperatives(DB, config.AllowedCooperatives)
+	if err := ValidateIDFormats(CooperativeIDs()); err != nil {
+		log.Fatalf("Invalid ID format: %v", err)
+	}
+	SeedSequences(DB)
 	StartExpirationWorker(DB)
 
 	if err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
 
-	log.Println("🚀 Connected Successfully to the Database")
+	log.Printf("🚀 Connected Successfully to the Database (%s)", dialector.Name())
 }
@@ -0,0 +1,38 @@
+package initializers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shyamsundaar/karino-mock-server/models/products"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestIsDuplicateKey(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&products.Product{}); err != nil {
+		t.Fatal(err)
+	}
+	previous := DB
+	DB = db
+	t.Cleanup(func() { DB = previous })
+
+	if err := db.Create(&products.Product{ProductCode: "IIT-101"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	err = db.Create(&products.Product{ProductCode: "IIT-101"}).Error
+	if !IsDuplicateKey(err) {
+		t.Errorf("IsDuplicateKey(%v) = false, want true", err)
+	}
+
+	for _, err := range []error{nil, errors.New("boom"), gorm.ErrRecordNotFound} {
+		if IsDuplicateKey(err) {
+			t.Errorf("IsDuplicateKey(%v) = true, want false", err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package initializers
+
+import (
+	"log"
+
+	"github.com/shyamsundaar/karino-mock-server/models/delivery"
+	"github.com/shyamsundaar/karino-mock-server/models/deliveryproof"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"gorm.io/gorm"
+)
+
+// legacyWaybillOrderIndex made order_id unique on way_bill, with waybill
+// items pointing at it (fk_way_bill_items), when orders had one delivery
+const legacyWaybillOrderIndex = "idx_way_bill_order_id"
+
+// MigrateWaybills drops the one-waybill-per-order index of an existing
+// database, so an order can have a proof for each delivery document. It
+// runs before AutoMigrate, which links the items to their waybill instead.
+func MigrateWaybills(db *gorm.DB) {
+	m := db.Migrator()
+	if !m.HasTable(&deliveryproof.Waybill{}) || !m.HasIndex(&deliveryproof.Waybill{}, legacyWaybillOrderIndex) {
+		return
+	}
+
+	if m.HasConstraint(&deliveryproof.WaybillItem{}, "fk_way_bill_items") {
+		if err := m.DropConstraint(&deliveryproof.WaybillItem{}, "fk_way_bill_items"); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	}
+	if err := m.DropIndex(&deliveryproof.Waybill{}, legacyWaybillOrderIndex); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	log.Println("✅ Waybills migrated to one per delivery document")
+}
+
+// BackfillDeliveries fills in the columns added for partial deliveries on
+// data created before them: waybill items get their waybill, and delivery
+// documents, which then always delivered the full item, get its quantity.
+func BackfillDeliveries(db *gorm.DB) {
+	err := db.Exec(`UPDATE way_bill_items SET waybill_id =
+		(SELECT MIN(way_bill.id) FROM way_bill WHERE way_bill.order_id = way_bill_items.order_id)
+		WHERE waybill_id IS NULL`).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to backfill way_bill_items.waybill_id: %v", err)
+	}
+
+	var legacy []delivery.CreateDeliveryDocuments
+	db.Where("quantity = 0").Find(&legacy)
+	for _, line := range legacy {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var item sales.SalesOrderItem
+			if err := tx.Where("order_id = ? AND order_item_id = ?", line.OrderID, line.OrderItemID).First(&item).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&line).UpdateColumn("quantity", item.Quantity).Error; err != nil {
+				return err
+			}
+			return tx.Model(&item).UpdateColumn("delivered_quantity", gorm.Expr("delivered_quantity + ?", item.Quantity)).Error
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to backfill the quantity of delivery document %s: %v", line.DeliveryDocumentCode, err)
+		}
+	}
+}
@@ -4,28 +4,36 @@ import (
 	"log"
 	"time"
 
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/models/delivery"
 	"gorm.io/gorm"
 )
 
-
+// MarkExpiredRows flips delivery documents older than expirationSeconds to EXPIRED.
+// The cutoff is computed in Go so the statement runs unchanged on MySQL and SQLite.
 func MarkExpiredRows(db *gorm.DB, expirationSeconds int) error {
+	cutoff := clock.Now().UTC().Add(-time.Duration(expirationSeconds) * time.Second)
 
-	query := `
-	UPDATE delivery_documents
-	SET status = 'EXPIRED'
-	WHERE status = 'NOT EXPIRED'
-	  AND id_created_at IS NOT NULL
-	  AND id_created_at + INTERVAL ? SECOND <= NOW()
-	`
-
-	return db.Exec(query, expirationSeconds).Error
+	return db.
+		Model(&delivery.CreateDeliveryDocuments{}).
+		Where("status = ? AND id_created_at IS NOT NULL AND id_created_at <= ?", "NOT EXPIRED", cutoff).
+		UpdateColumn("status", "EXPIRED").
+		Error
 }
 
+// StartExpirationWorker runs MarkExpiredRows every minute and right after
+// the virtual clock is frozen, set or advanced.
 func StartExpirationWorker(db *gorm.DB) {
 	ticker := time.NewTicker(1 * time.Minute)
+	clockChanged := clock.Subscribe()
 
 	go func() {
-		for range ticker.C {
+		for {
+			select {
+			case <-ticker.C:
+			case <-clockChanged:
+			}
+
 			err := MarkExpiredRows(
 				db,
 				AppConfig.ExpirationTimeSeconds,
@@ -36,4 +44,3 @@ func StartExpirationWorker(db *gorm.DB) {
 		}
 	}()
 }
-
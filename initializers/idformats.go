@@ -0,0 +1,87 @@
+package initializers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
+	"github.com/spf13/viper"
+)
+
+// Config keys for ERP identifier templates (see sequences.IDFormat for the syntax).
+// A cooperative can override any of them with a "<KEY>_<COOPID>" entry,
+// e.g. CUSTOMER_ID_FORMAT_COOP029=K{COOP}-{YYYY}-{SEQ:6:yearly}.
+const (
+	CustomerIDFormat           = "CUSTOMER_ID_FORMAT"
+	VendorIDFormat             = "VENDOR_ID_FORMAT"
+	SalesOrderIDFormat         = "SALES_ORDER_ID_FORMAT"
+	SalesOrderCodeFormat       = "SALES_ORDER_CODE_FORMAT"
+	DeliveryDocumentCodeFormat = "DELIVERY_DOCUMENT_CODE_FORMAT"
+	InvoiceIDFormat            = "INVOICE_ID_FORMAT"
+	InvoiceCodeFormat          = "INVOICE_CODE_FORMAT"
+)
+
+// Formats used when nothing is configured; they match the historical hard-coded IDs
+var defaultIDFormats = map[string]string{
+	CustomerIDFormat:           "C26{SEQ:5}",
+	VendorIDFormat:             "F26{SEQ:5}",
+	SalesOrderIDFormat:         "{UUID}",
+	SalesOrderCodeFormat:       "ECL 2025/{SEQ}",
+	DeliveryDocumentCodeFormat: "GT2 2025/{SEQ}",
+	InvoiceIDFormat:            "{UUID}",
+	InvoiceCodeFormat:          "FT 2025/{SEQ}",
+}
+
+// IDFormatFor returns the template for key, preferring the cooperative's override.
+// perCoop reports whether the override was used, in which case the cooperative
+// gets its own counter.
+func IDFormatFor(key, coopId string) (template string, perCoop bool) {
+	if coopId != "" {
+		if t := viper.GetString(key + "_" + coopId); t != "" {
+			return t, true
+		}
+	}
+	if t := viper.GetString(key); t != "" {
+		return t, false
+	}
+	return defaultIDFormats[key], false
+}
+
+// ResolveIDFormat parses the template IDFormatFor picks for key and coopId.
+// A cooperative's override must pass the stricter ParseCoopIDFormat.
+func ResolveIDFormat(key, coopId string) (format *sequences.IDFormat, perCoop bool, err error) {
+	template, perCoop := IDFormatFor(key, coopId)
+	if perCoop {
+		format, err = sequences.ParseCoopIDFormat(template)
+		if err != nil {
+			return nil, true, fmt.Errorf("%s_%s: %w", key, coopId, err)
+		}
+		return format, true, nil
+	}
+
+	format, err = sequences.ParseIDFormat(template)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", key, err)
+	}
+	return format, false, nil
+}
+
+// ValidateIDFormats resolves every template for the global scope and for
+// each of coopIds, the way the generators do, so typos fail at startup.
+// Overrides set only as environment variables are covered too.
+func ValidateIDFormats(coopIds []string) error {
+	keys := make([]string, 0, len(defaultIDFormats))
+	for key := range defaultIDFormats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, coopId := range append([]string{""}, coopIds...) {
+			if _, _, err := ResolveIDFormat(key, coopId); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
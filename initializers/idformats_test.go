@@ -0,0 +1,35 @@
+package initializers
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestValidateIDFormatsFromEnvironment(t *testing.T) {
+	viper.AutomaticEnv()
+
+	tests := []struct {
+		name, key, template string
+		wantErr             bool
+	}{
+		{"valid override", "CUSTOMER_ID_FORMAT_COOP077", "K{COOP}-{YYYY}-{SEQ:6:yearly}", false},
+		{"override without {COOP}", "CUSTOMER_ID_FORMAT_COOP077", "K{YYYY}-{SEQ:6}", true},
+		{"yearly override without a year", "INVOICE_CODE_FORMAT_COOP077", "FT {COOP}/{SEQ:yearly}", true},
+		{"invalid global format", "VENDOR_ID_FORMAT", "F{SEQ:x}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.template)
+			err := ValidateIDFormats([]string{"COOP077"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIDFormats with %s=%s: error = %v, want error %v", tt.key, tt.template, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ValidateIDFormats([]string{"COOP077"}); err != nil {
+		t.Errorf("ValidateIDFormats with the defaults: %v", err)
+	}
+}
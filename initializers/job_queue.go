@@ -0,0 +1,307 @@
+package initializers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/models/jobs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// JobHandler performs one job; returning an error schedules a retry
+type JobHandler func(ctx context.Context, job *jobs.Job) error
+
+var (
+	jobHandlersMu sync.RWMutex
+	jobHandlers   = map[string]JobHandler{}
+
+	// jobWakeup lets callers skip the poll interval
+	jobWakeup = make(chan struct{}, 1)
+
+	ErrJobNotFound    = errors.New("job not found")
+	ErrJobNotRunnable = errors.New("job is running or already completed")
+)
+
+const (
+	jobPollInterval   = 1 * time.Second
+	jobMaxBackoff     = 5 * time.Minute
+	defaultJobWorkers = 4
+	defaultJobRetries = 5
+)
+
+// RegisterJobHandler sets the function that runs jobs of the given type
+func RegisterJobHandler(jobType string, handler JobHandler) {
+	jobHandlersMu.Lock()
+	defer jobHandlersMu.Unlock()
+	jobHandlers[jobType] = handler
+}
+
+// EnqueueJob schedules a job for entityID to run after delay. If an
+// unfinished job of the same type already exists for the entity it is
+// returned instead, so repeated requests do not pile up work.
+// Pass the caller's transaction as db to enqueue atomically with the entity.
+func EnqueueJob(db *gorm.DB, jobType, coopId string, entityID uint, entityRef string, delay time.Duration) (*jobs.Job, error) {
+	var existing jobs.Job
+	err := db.
+		Where("type = ? AND entity_id = ? AND status IN ?", jobType, entityID, []string{jobs.StatusPending, jobs.StatusRunning}).
+		First(&existing).
+		Error
+	if err == nil {
+		return &existing, nil
+	}
+
+	maxAttempts := AppConfig.JobMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultJobRetries
+	}
+
+	job := jobs.Job{
+		Type:        jobType,
+		CoopID:      coopId,
+		EntityID:    entityID,
+		EntityRef:   entityRef,
+		Status:      jobs.StatusPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       clock.Now().UTC().Add(delay),
+	}
+	if err := db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	WakeJobWorkers()
+	return &job, nil
+}
+
+// WakeJobWorkers makes the dispatcher look for due jobs now
+func WakeJobWorkers() {
+	select {
+	case jobWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// StartJobWorkers resumes jobs interrupted by a restart and starts the
+// dispatcher plus a pool of workers. Register handlers before calling it.
+func StartJobWorkers(db *gorm.DB) {
+	workers := AppConfig.JobWorkers
+	if workers <= 0 {
+		workers = defaultJobWorkers
+	}
+
+	// A RUNNING job at startup was cut off by the previous process
+	if err := db.Model(&jobs.Job{}).
+		Where("status = ?", jobs.StatusRunning).
+		Updates(map[string]interface{}{"status": jobs.StatusPending, "locked_at": nil}).
+		Error; err != nil {
+		log.Println("job queue recovery error:", err)
+	}
+
+	queue := make(chan jobs.Job)
+	for range workers {
+		go func() {
+			for job := range queue {
+				runJob(db, &job)
+			}
+		}()
+	}
+
+	// Jobs scheduled in virtual time become due when the clock jumps
+	clockChanged := clock.Subscribe()
+
+	go func() {
+		ticker := time.NewTicker(jobPollInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := dispatchDueJobs(db, queue, workers); err != nil {
+				log.Println("job queue error:", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-jobWakeup:
+			case <-clockChanged:
+			}
+		}
+	}()
+
+	log.Printf("✅ Job queue started with %d workers", workers)
+}
+
+// dispatchDueJobs claims due jobs and hands them to the workers
+func dispatchDueJobs(db *gorm.DB, queue chan<- jobs.Job, limit int) error {
+	// The poll runs every second; keep it out of the SQL log
+	quiet := db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Silent)})
+
+	var due []jobs.Job
+	if err := quiet.
+		Where("status = ? AND run_at <= ?", jobs.StatusPending, clock.Now().UTC()).
+		Order("run_at").
+		Limit(limit).
+		Find(&due).
+		Error; err != nil {
+		return err
+	}
+
+	for _, job := range due {
+		claimed, err := claimJob(db, job.ID, jobs.StatusPending)
+		if err != nil {
+			return err
+		}
+		if claimed != nil {
+			queue <- *claimed
+		}
+	}
+
+	return nil
+}
+
+// claimJob moves a job from one of the given statuses to RUNNING. It
+// returns nil without error when another caller got there first.
+func claimJob(db *gorm.DB, id uint, from ...string) (*jobs.Job, error) {
+	res := db.Model(&jobs.Job{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(map[string]interface{}{
+			"status":    jobs.StatusRunning,
+			"locked_at": clock.Now().UTC(),
+			"attempts":  gorm.Expr("attempts + 1"),
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var job jobs.Job
+	if err := db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RetryJob makes a pending or failed job due now. A failed job gets a
+// fresh set of attempts.
+func RetryJob(db *gorm.DB, id uint) (*jobs.Job, error) {
+	var job jobs.Job
+	if err := db.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"run_at": clock.Now().UTC()}
+	if job.Status == jobs.StatusFailed {
+		updates["status"] = jobs.StatusPending
+		updates["attempts"] = 0
+	}
+
+	res := db.Model(&jobs.Job{}).
+		Where("id = ? AND status IN ?", id, []string{jobs.StatusPending, jobs.StatusFailed}).
+		Updates(updates)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrJobNotRunnable
+	}
+
+	WakeJobWorkers()
+
+	if err := db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RunJobNow runs a pending or failed job on the caller's goroutine,
+// ignoring its scheduled time, and returns the job with its outcome.
+func RunJobNow(db *gorm.DB, id uint) (*jobs.Job, error) {
+	claimed, err := claimJob(db, id, jobs.StatusPending, jobs.StatusFailed)
+	if err != nil {
+		return nil, err
+	}
+	if claimed == nil {
+		if err := db.First(&jobs.Job{}, id).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, ErrJobNotRunnable
+	}
+
+	runJob(db, claimed)
+
+	var job jobs.Job
+	if err := db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func runJob(db *gorm.DB, job *jobs.Job) {
+	jobHandlersMu.RLock()
+	handler, ok := jobHandlers[job.Type]
+	jobHandlersMu.RUnlock()
+
+	var err error
+	if !ok {
+		err = fmt.Errorf("no handler registered for job type %s", job.Type)
+	} else {
+		err = safeRunJob(handler, job)
+	}
+
+	now := clock.Now().UTC()
+	updates := map[string]interface{}{"locked_at": nil}
+
+	switch {
+	case err == nil:
+		updates["status"] = jobs.StatusCompleted
+		updates["completed_at"] = now
+		updates["last_error"] = ""
+
+	case job.Attempts >= job.MaxAttempts:
+		log.Printf("❌ Job %d (%s) failed permanently: %v", job.ID, job.Type, err)
+		updates["status"] = jobs.StatusFailed
+		updates["last_error"] = err.Error()
+
+	default:
+		log.Printf("⚠️ Job %d (%s) attempt %d failed, retrying: %v", job.ID, job.Type, job.Attempts, err)
+		updates["status"] = jobs.StatusPending
+		updates["run_at"] = now.Add(jobBackoff(job.Attempts))
+		updates["last_error"] = err.Error()
+	}
+
+	// Only the run that holds the claim may record its outcome
+	if err := db.Model(&jobs.Job{}).
+		Where("id = ? AND status = ?", job.ID, jobs.StatusRunning).
+		Updates(updates).
+		Error; err != nil {
+		log.Println("job queue error:", err)
+	}
+}
+
+// safeRunJob turns a handler panic into a job failure instead of killing the worker
+func safeRunJob(handler JobHandler, job *jobs.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(context.Background(), job)
+}
+
+// jobBackoff is exponential (2s, 4s, 8s, ...) and capped at jobMaxBackoff
+func jobBackoff(attempts int) time.Duration {
+	d := time.Duration(math.Pow(2, float64(attempts))) * time.Second
+	if d > jobMaxBackoff {
+		return jobMaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,51 @@
+package initializers
+
+import (
+	"log"
+
+	"github.com/shyamsundaar/karino-mock-server/models/journal"
+)
+
+// Journal records /spic_to_erp traffic (see middleware.Journal)
+var Journal *journal.Journal
+
+const (
+	defaultJournalPath       = "journal.jsonl"
+	defaultJournalMaxBytes   = 10 << 20
+	defaultJournalMaxFiles   = 5
+	defaultJournalBufferSize = 1000
+)
+
+// InitJournal opens the request journal. JOURNAL_PATH=off keeps it in memory only.
+func InitJournal(config *Config) {
+	path := config.JournalPath
+	switch path {
+	case "":
+		path = defaultJournalPath
+	case "off":
+		path = ""
+	}
+
+	maxBytes := config.JournalMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultJournalMaxBytes
+	}
+	maxFiles := config.JournalMaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultJournalMaxFiles
+	}
+	bufferSize := config.JournalBufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultJournalBufferSize
+	}
+
+	var err error
+	Journal, err = journal.New(path, maxBytes, maxFiles, bufferSize)
+	if err != nil {
+		log.Fatal("Failed to open the request journal! \n", err.Error())
+	}
+
+	if path != "" {
+		log.Printf("✅ Journaling /spic_to_erp traffic to %s", path)
+	}
+}
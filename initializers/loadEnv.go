@@ -5,6 +5,8 @@ import (
 )
 
 type Config struct {
+	DBDriver       string `mapstructure:"DB_DRIVER"`
+	SQLitePath     string `mapstructure:"SQLITE_PATH"`
 	DBHost         string `mapstructure:"MYSQL_HOST"`
 	DBUserName     string `mapstructure:"MYSQL_USER"`
 	DBUserPassword string `mapstructure:"MYSQL_PASSWORD"`
@@ -17,8 +19,33 @@ type Config struct {
 	CustomerTimeSeconds int    `mapstructure:"CUSTOMER_TIME_SECONDS"`
 	VendorTimeSeconds   int    `mapstructure:"VENDOR_TIME_SECONDS"`
 	SalesTimeSeconds    int    `mapstructure:"SALES_TIME_SECONDS"`
+	InvoiceTimeSeconds  int    `mapstructure:"INVOICE_TIME_SECONDS"`
 	ExpirationTimeHour	int    `mapstructure:"EXPIRATION_TIME_HOURS"`
 	ExpirationTimeSeconds	int    `mapstructure:"EXPIRATION_TIME_SECONDS"`
+	DBLogLevel          string `mapstructure:"DB_LOG_LEVEL"`
+	JobWorkers          int    `mapstructure:"JOB_WORKERS"`
+	JobMaxAttempts      int    `mapstructure:"JOB_MAX_ATTEMPTS"`
+
+	JournalPath       string `mapstructure:"JOURNAL_PATH"`
+	JournalMaxBytes   int64  `mapstructure:"JOURNAL_MAX_BYTES"`
+	JournalMaxFiles   int    `mapstructure:"JOURNAL_MAX_FILES"`
+	JournalBufferSize int    `mapstructure:"JOURNAL_BUFFER_SIZE"`
+
+	AuthMode                  string `mapstructure:"AUTH_MODE"`
+	JWTSecret                 string `mapstructure:"JWT_SECRET"`
+	OAuthClientID             string `mapstructure:"OAUTH_CLIENT_ID"`
+	OAuthTokenLifetimeSeconds int    `mapstructure:"OAUTH_TOKEN_LIFETIME_SECONDS"`
+
+	SignatureMode           string `mapstructure:"SIGNATURE_MODE"`
+	SignatureSecret         string `mapstructure:"SIGNATURE_SECRET"`
+	SignatureMaxSkewSeconds int    `mapstructure:"SIGNATURE_MAX_SKEW_SECONDS"`
+
+	PricingMode string `mapstructure:"PRICING_MODE"`
+
+	PhotoStoreDir     string `mapstructure:"PHOTO_STORE_DIR"`
+	PhotoMaxBytes     int64  `mapstructure:"PHOTO_MAX_BYTES"`
+	PhotoMaxCount     int    `mapstructure:"PHOTO_MAX_COUNT"`
+	PhotoAllowedTypes string `mapstructure:"PHOTO_ALLOWED_TYPES"`
 }
 
 var AppConfig Config
@@ -0,0 +1,53 @@
+package initializers
+
+import (
+	"crypto/rand"
+	"log"
+	"time"
+)
+
+const (
+	defaultOAuthClientID      = "karino-mock"
+	defaultOAuthTokenLifetime = time.Hour
+)
+
+var (
+	tokenSecret   []byte
+	tokenLifetime = defaultOAuthTokenLifetime
+	oauthClientID = defaultOAuthClientID
+)
+
+// InitOAuth loads the token signing secret and lifetime. Without JWT_SECRET a
+// random secret is generated, so tokens don't survive a restart.
+func InitOAuth(config *Config) {
+	tokenSecret = []byte(config.JWTSecret)
+	if len(tokenSecret) == 0 {
+		tokenSecret = make([]byte, 32)
+		if _, err := rand.Read(tokenSecret); err != nil {
+			log.Fatal("Failed to generate the JWT secret! \n", err.Error())
+		}
+		log.Println("⚠️ JWT_SECRET not set, access tokens are signed with a random per-process secret")
+	}
+
+	if config.OAuthTokenLifetimeSeconds > 0 {
+		tokenLifetime = time.Duration(config.OAuthTokenLifetimeSeconds) * time.Second
+	}
+	if config.OAuthClientID != "" {
+		oauthClientID = config.OAuthClientID
+	}
+}
+
+// TokenSecret is the HS256 key for access tokens
+func TokenSecret() []byte {
+	return tokenSecret
+}
+
+// TokenLifetime is the default (and maximum) access token lifetime
+func TokenLifetime() time.Duration {
+	return tokenLifetime
+}
+
+// OAuthClientID is the client_id that pairs with the master APIKey as secret
+func OAuthClientID() string {
+	return oauthClientID
+}
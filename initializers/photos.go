@@ -0,0 +1,110 @@
+package initializers
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shyamsundaar/karino-mock-server/models/deliveryproof"
+	"github.com/shyamsundaar/karino-mock-server/models/photos"
+	"gorm.io/gorm"
+)
+
+// Photos stores uploaded delivery photos
+var Photos *photos.Store
+
+const (
+	defaultPhotoStoreDir     = "photos"
+	defaultPhotoMaxBytes     = 5 << 20
+	defaultPhotoMaxCount     = 10
+	defaultPhotoAllowedTypes = "image/jpeg,image/png,image/webp"
+)
+
+// InitPhotoStore opens the delivery photo blob store
+func InitPhotoStore(config *Config) {
+	if config.PhotoStoreDir == "" {
+		config.PhotoStoreDir = defaultPhotoStoreDir
+	}
+	if config.PhotoMaxBytes <= 0 {
+		config.PhotoMaxBytes = defaultPhotoMaxBytes
+	}
+	if config.PhotoMaxCount <= 0 {
+		config.PhotoMaxCount = defaultPhotoMaxCount
+	}
+	if strings.TrimSpace(config.PhotoAllowedTypes) == "" {
+		config.PhotoAllowedTypes = defaultPhotoAllowedTypes
+	}
+	AppConfig.PhotoStoreDir = config.PhotoStoreDir
+	AppConfig.PhotoMaxBytes = config.PhotoMaxBytes
+	AppConfig.PhotoMaxCount = config.PhotoMaxCount
+	AppConfig.PhotoAllowedTypes = config.PhotoAllowedTypes
+
+	var err error
+	Photos, err = photos.NewStore(config.PhotoStoreDir, config.PhotoMaxBytes, strings.Split(config.PhotoAllowedTypes, ","))
+	if err != nil {
+		log.Fatal("Failed to open the photo store! \n", err.Error())
+	}
+	log.Printf("✅ Storing delivery photos in %s", config.PhotoStoreDir)
+}
+
+// PhotoBodyLimit is the request body limit needed to upload a waybill's
+// photos in one request, plus room for the rest of the form
+func PhotoBodyLimit() int {
+	maxBytes, maxCount := AppConfig.PhotoMaxBytes, AppConfig.PhotoMaxCount
+	if maxBytes <= 0 {
+		maxBytes = defaultPhotoMaxBytes
+	}
+	if maxCount <= 0 {
+		maxCount = defaultPhotoMaxCount
+	}
+	return int(maxBytes)*maxCount + 1<<20
+}
+
+// legacyWaybillPhotos is how waybills stored their photos before the blob
+// store: a JSON array holding the url1/url2 proof fields
+type legacyWaybillPhotos []struct {
+	URL1 string `json:"url1"`
+	URL2 string `json:"url2"`
+}
+
+// BackfillWaybillPhotos rewrites the url1/url2 photos of waybills stored
+// before the blob store as linked photos, which would otherwise decode as
+// empty photos and lose their URLs
+func BackfillWaybillPhotos(db *gorm.DB) {
+	var legacy []struct {
+		ID             uint
+		DeliveryPhotos string
+		CreatedAt      time.Time
+	}
+	err := db.Model(&deliveryproof.Waybill{}).
+		Select("id, delivery_photos, created_at").
+		Where("delivery_photos LIKE ? OR delivery_photos LIKE ?", `%"url1"%`, `%"url2"%`).
+		Scan(&legacy).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to read legacy waybill photos: %v", err)
+		return
+	}
+
+	for _, waybill := range legacy {
+		var old legacyWaybillPhotos
+		if err := json.Unmarshal([]byte(waybill.DeliveryPhotos), &old); err != nil {
+			log.Printf("⚠️ Failed to parse the photos of waybill %d: %v", waybill.ID, err)
+			continue
+		}
+
+		linked := make([]photos.Photo, 0, 2*len(old))
+		for _, entry := range old {
+			linked = append(linked, photos.Linked(waybill.CreatedAt.UTC(), entry.URL1, entry.URL2)...)
+		}
+		err := db.Model(&deliveryproof.Waybill{ID: waybill.ID}).
+			Select("DeliveryPhotos").
+			Updates(&deliveryproof.Waybill{DeliveryPhotos: linked}).Error
+		if err != nil {
+			log.Printf("⚠️ Failed to backfill the photos of waybill %d: %v", waybill.ID, err)
+		}
+	}
+	if len(legacy) > 0 {
+		log.Printf("✅ Backfilled the photos of %d waybills", len(legacy))
+	}
+}
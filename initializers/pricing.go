@@ -0,0 +1,31 @@
+package initializers
+
+import (
+	"log"
+	"strings"
+
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+)
+
+// InitPricing validates PRICING_MODE
+func InitPricing(config *Config) {
+	mode := strings.ToLower(strings.TrimSpace(config.PricingMode))
+	switch mode {
+	case "":
+		mode = sales.PricingPriceList
+	case sales.PricingPriceList, sales.PricingRandom:
+	default:
+		log.Fatalf("unsupported PRICING_MODE %q (use pricelist or random)", config.PricingMode)
+	}
+	config.PricingMode = mode
+	AppConfig.PricingMode = mode
+
+	if mode == sales.PricingRandom {
+		log.Println("⚠️ PRICING_MODE=random: sales order amounts ignore the price list")
+	}
+}
+
+// RandomPricing reports whether sales orders get the legacy random amounts
+func RandomPricing() bool {
+	return AppConfig.PricingMode == sales.PricingRandom
+}
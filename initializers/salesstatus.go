@@ -0,0 +1,70 @@
+package initializers
+
+import (
+	"log"
+
+	"github.com/shyamsundaar/karino-mock-server/models/delivery"
+	"github.com/shyamsundaar/karino-mock-server/models/invoices"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"gorm.io/gorm"
+)
+
+// SalesOrderInvoiced reports whether every delivery document of the order
+// that was not voided has an issued invoice
+func SalesOrderInvoiced(db *gorm.DB, coopId, orderId string) bool {
+	documents := db.Model(&delivery.CreateDeliveryDocuments{}).
+		Select("DISTINCT delivery_document_id").
+		Where("coop_id = ? AND order_id = ? AND status <> ?", coopId, orderId, "VOIDED")
+
+	var total, invoiced int64
+	db.Table("(?) AS documents", documents).Count(&total)
+	if total == 0 {
+		return false
+	}
+	db.Model(&invoices.Invoice{}).
+		Where("coop_id = ? AND order_id = ? AND status = ? AND delivery_document_id IN (?)",
+			coopId, orderId, invoices.StatusIssued, documents).
+		Distinct("delivery_document_id").
+		Count(&invoiced)
+	return invoiced == total
+}
+
+// BackfillSalesOrderStatuses moves orders stored before the lifecycle
+// existed (OPEN) to the status their ERP ID, deliveries and invoices put
+// them in. It runs after BackfillDeliveries, which sets the delivered
+// quantities it reads.
+func BackfillSalesOrderStatuses(db *gorm.DB) {
+	var legacy []sales.SalesOrder
+	db.Where("status = ?", sales.StatusOpen).Find(&legacy)
+
+	for _, order := range legacy {
+		status := sales.StatusReceived
+		if order.ErpSalesOrderId != "" {
+			var items []sales.SalesOrderItem
+			db.Where("order_id = ?", order.OrderID).Find(&items)
+			status = sales.DeliveryStatus(items)
+			if status == sales.StatusDelivered && SalesOrderInvoiced(db, order.CoopID, order.OrderID) {
+				status = sales.StatusInvoiced
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&order).UpdateColumn("status", status).Error; err != nil {
+				return err
+			}
+			return tx.Create(&sales.SalesOrderStatusChange{
+				CoopID:     order.CoopID,
+				OrderID:    order.OrderID,
+				FromStatus: sales.StatusOpen,
+				ToStatus:   status,
+				Reason:     "Migrated to the order lifecycle",
+			}).Error
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to backfill the status of sales order %s: %v", order.OrderID, err)
+		}
+	}
+	if len(legacy) > 0 {
+		log.Printf("✅ Backfilled the status of %d sales orders", len(legacy))
+	}
+}
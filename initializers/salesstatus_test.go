@@ -0,0 +1,97 @@
+package initializers
+
+import (
+	"testing"
+
+	"github.com/shyamsundaar/karino-mock-server/models/delivery"
+	"github.com/shyamsundaar/karino-mock-server/models/invoices"
+	"github.com/shyamsundaar/karino-mock-server/models/sales"
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// baselineSalesOrder and baselineSalesOrderItem are the tables as they were
+// before sales orders had a status or delivered quantities
+type baselineSalesOrder struct {
+	ID                uint   `gorm:"primaryKey;autoIncrement"`
+	TempID            string `gorm:"column:temp_id;not null"`
+	CoopID            string `gorm:"column:coop_id;not null"`
+	ErpSalesOrderId   string `gorm:"column:erp_sales_order_id;size:64"`
+	ErpSalesOrderCode string `gorm:"column:erp_sales_order_code;size:64"`
+	OrderID           string `gorm:"column:order_id;size:64;uniqueIndex"`
+}
+
+func (baselineSalesOrder) TableName() string {
+	return "sales_orders"
+}
+
+type baselineSalesOrderItem struct {
+	ID          uint    `gorm:"primaryKey;autoIncrement"`
+	OrderID     string  `gorm:"column:order_id;size:64;index;not null"`
+	OrderItemID string  `gorm:"column:order_item_id;size:64"`
+	Quantity    float64 `gorm:"column:quantity"`
+}
+
+func (baselineSalesOrderItem) TableName() string {
+	return "sales_order_items"
+}
+
+func TestBackfillSalesOrderStatusesFromBaseline(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&baselineSalesOrder{}, &baselineSalesOrderItem{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]baselineSalesOrder{
+		{TempID: "1000", CoopID: "COOP019", OrderID: "WITH-ID", ErpSalesOrderId: "SO-1", ErpSalesOrderCode: "C-1"},
+		{TempID: "1001", CoopID: "COOP019", OrderID: "WITHOUT-ID"},
+	})
+	db.Create(&[]baselineSalesOrderItem{
+		{OrderID: "WITH-ID", OrderItemID: "I1", Quantity: 4},
+		{OrderID: "WITHOUT-ID", OrderItemID: "I1", Quantity: 2},
+	})
+
+	err = db.AutoMigrate(&sales.SalesOrder{}, &sales.SalesOrderItem{}, &sales.SalesOrderStatusChange{},
+		&delivery.CreateDeliveryDocuments{}, &invoices.Invoice{}, &sequences.Sequence{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	BackfillSalesOrderStatuses(db)
+	SeedSequences(db)
+
+	want := map[string]string{
+		"WITH-ID":    sales.StatusConfirmed,
+		"WITHOUT-ID": sales.StatusReceived,
+	}
+	for orderId, status := range want {
+		var order sales.SalesOrder
+		if err := db.Where("order_id = ?", orderId).First(&order).Error; err != nil {
+			t.Fatal(err)
+		}
+		if order.Status != status {
+			t.Errorf("order %s: status = %s, want %s", orderId, order.Status, status)
+		}
+
+		var history []sales.SalesOrderStatusChange
+		db.Where("order_id = ?", orderId).Find(&history)
+		if len(history) != 1 || history[0].FromStatus != sales.StatusOpen || history[0].ToStatus != status {
+			t.Errorf("order %s: history = %+v, want one move from OPEN to %s", orderId, history, status)
+		}
+	}
+
+	// Orders created after the migration start out RECEIVED
+	order := sales.SalesOrder{CoopID: "COOP019", OrderID: "NEW"}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	var stored sales.SalesOrder
+	db.First(&stored, order.ID)
+	if stored.Status != sales.StatusReceived {
+		t.Errorf("new order: status = %s, want %s", stored.Status, sales.StatusReceived)
+	}
+}
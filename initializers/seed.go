@@ -2,6 +2,7 @@ package initializers
 
 import (
 	"log"
+	"strconv"
 
 	"github.com/shyamsundaar/karino-mock-server/models/products"
 	"gorm.io/gorm"
@@ -15,6 +16,7 @@ func SeedInitialData(db *gorm.DB) {
 	db.Model(&products.Product{}).Count(&count)
 	if count > 0 {
 		log.Println("ℹ️ Products already seeded, skipping")
+		seedPriceList(db)
 		return
 	}
 
@@ -31,6 +33,11 @@ func SeedInitialData(db *gorm.DB) {
 		{ProductCode: "IIT-109"},
 		{ProductCode: "IIT-110"},
 	}
+	for i := range productList {
+		productList[i].Name = "Input item " + productList[i].ProductCode
+		productList[i].UnitOfMeasure = "UN"
+		productList[i].UnitPrice = seedUnitPrice(i)
+	}
 
 	if err := db.Create(&productList).Error; err != nil {
 		log.Fatal("❌ Failed to seed products:", err)
@@ -38,3 +45,20 @@ func SeedInitialData(db *gorm.DB) {
 
 	log.Println("✅ Products seeded successfully")
 }
+
+// seedUnitPrice is the price list entry of the i-th seeded product:
+// IIT-101 costs 100.00, IIT-102 110.00 and so on
+func seedUnitPrice(i int) float64 {
+	return 100 + 10*float64(i)
+}
+
+// seedPriceList prices the seeded products of a database created before the
+// price list existed. Prices set through the admin API are kept.
+func seedPriceList(db *gorm.DB) {
+	for i := 0; i < 10; i++ {
+		code := "IIT-" + strconv.Itoa(101+i)
+		db.Model(&products.Product{}).
+			Where("product_code = ? AND (unit_price IS NULL OR unit_price = 0)", code).
+			Update("unit_price", seedUnitPrice(i))
+	}
+}
@@ -0,0 +1,80 @@
+package initializers
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
+	"gorm.io/gorm"
+)
+
+// sequenceSource says where a counter's last value can be recovered from
+// in data written before the sequences table existed
+type sequenceSource struct {
+	name    string
+	table   string
+	column  string
+	pattern *regexp.Regexp
+	start   int64
+}
+
+var sequenceSources = []sequenceSource{
+	{sequences.FarmerTempID, "farmer_details", "temp_id", regexp.MustCompile(`^\d+$`), 1000},
+	{sequences.SalesOrderTempID, "sales_orders", "temp_id", regexp.MustCompile(`^\d+$`), 1000},
+	{sequences.WaybillTempID, "way_bill", "temp_id", regexp.MustCompile(`^\d+$`), 1000},
+	{sequences.CustomerID, "farmer_details", "customer_id", regexp.MustCompile(`\d{5}$`), 1},
+	{sequences.VendorID, "farmer_details", "vendor_id", regexp.MustCompile(`\d{5}$`), 1},
+	{sequences.ErpSalesOrderCode, "sales_orders", "erp_sales_order_code", regexp.MustCompile(`\d+$`), 1},
+	{sequences.DeliveryDocumentCode, "delivery_documents", "delivery_document_code", regexp.MustCompile(`\d+$`), 1},
+	{sequences.InvoiceCode, "invoices", "erp_invoice_code", regexp.MustCompile(`\d+$`), 1},
+}
+
+// NormalizeGeneratedCodes turns empty generated codes into NULL so the
+// unique indexes on those columns can be created on existing databases.
+func NormalizeGeneratedCodes(db *gorm.DB) {
+	columns := map[string][]string{
+		"farmer_details": {"customer_id", "vendor_id"},
+		"sales_orders":   {"erp_sales_order_id", "erp_sales_order_code"},
+	}
+
+	for table, cols := range columns {
+		if !db.Migrator().HasTable(table) {
+			continue
+		}
+		for _, col := range cols {
+			if err := db.Table(table).Where(col+" = ?", "").Update(col, nil).Error; err != nil {
+				log.Printf("⚠️ Failed to normalize %s.%s: %v", table, col, err)
+			}
+		}
+	}
+}
+
+// SeedSequences creates missing counters, continuing from the highest value
+// already present in the data so upgraded databases never reissue a code.
+func SeedSequences(db *gorm.DB) {
+	for _, src := range sequenceSources {
+		last := src.start - 1
+
+		var values []string
+		db.Table(src.table).
+			Where(src.column+" IS NOT NULL AND "+src.column+" != ''").
+			Pluck(src.column, &values)
+
+		for _, v := range values {
+			m := src.pattern.FindString(v)
+			if m == "" {
+				continue
+			}
+			if n, err := strconv.ParseInt(m, 10, 64); err == nil && n > last {
+				last = n
+			}
+		}
+
+		if err := sequences.Seed(db, src.name, last); err != nil {
+			log.Fatalf("❌ Failed to seed sequence %s: %v", src.name, err)
+		}
+	}
+
+	log.Println("✅ Sequences ready")
+}
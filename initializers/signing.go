@@ -0,0 +1,45 @@
+package initializers
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shyamsundaar/karino-mock-server/signing"
+)
+
+// Supported values for SIGNATURE_MODE
+const (
+	SignatureModeOff      = "off"
+	SignatureModeRequired = "required"
+	SignatureModeCoop     = "coop"
+)
+
+const defaultSignatureMaxSkew = 5 * time.Minute
+
+// Signatures verifies HMAC-signed /spic_to_erp requests (see middleware.SignatureVerification)
+var Signatures = signing.NewVerifier(defaultSignatureMaxSkew)
+
+// InitSignatures validates SIGNATURE_MODE and sets the allowed clock skew
+func InitSignatures(config *Config) {
+	mode := strings.ToLower(strings.TrimSpace(config.SignatureMode))
+	switch mode {
+	case "":
+		mode = SignatureModeOff
+	case SignatureModeOff, SignatureModeRequired, SignatureModeCoop:
+	default:
+		log.Fatalf("unsupported SIGNATURE_MODE %q (use off, required or coop)", config.SignatureMode)
+	}
+	config.SignatureMode = mode
+	AppConfig.SignatureMode = mode
+
+	maxSkew := defaultSignatureMaxSkew
+	if config.SignatureMaxSkewSeconds > 0 {
+		maxSkew = time.Duration(config.SignatureMaxSkewSeconds) * time.Second
+	}
+	Signatures = signing.NewVerifier(maxSkew)
+
+	if mode != SignatureModeOff {
+		log.Printf("✅ Verifying request signatures (%s, ±%s)", mode, maxSkew)
+	}
+}
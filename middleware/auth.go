@@ -1,23 +1,152 @@
 package middleware
 
 import (
+	"regexp"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/clock"
 	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/apikeys"
+	"github.com/shyamsundaar/karino-mock-server/oauth"
 )
 
-// ApiKeyAuth validates the X-API-KEY header
+// Supported values for AUTH_MODE
+const (
+	AuthModeAPIKey = "apikey"
+	AuthModeBearer = "bearer"
+	AuthModeBoth   = "both"
+)
+
+// Auth authenticates /spic_to_erp requests according to AUTH_MODE: "apikey"
+// (ApiKeyAuth only), "bearer" (BearerAuth only) or, by default, "both", which
+// uses BearerAuth when an Authorization: Bearer header is sent.
+func Auth(c *fiber.Ctx) error {
+	switch strings.ToLower(initializers.AppConfig.AuthMode) {
+	case AuthModeAPIKey:
+		return ApiKeyAuth(c)
+	case AuthModeBearer:
+		return BearerAuth(c)
+	}
+
+	if _, ok := bearerToken(c); ok {
+		return BearerAuth(c)
+	}
+	return ApiKeyAuth(c)
+}
+
+// ApiKeyAuth validates the APIKey header. The global APIKey has full
+// access; any other key must be an active entry of the api_keys store that
+// owns the :coopId in the path and grants the request's scope.
 func ApiKeyAuth(c *fiber.Ctx) error {
-	expectedKey := initializers.AppConfig.ApiKey
 	clientKey := c.Get("APIKey")
+	if clientKey == "" {
+		return c.Status(fiber.StatusForbidden).SendString(`"Invalid or missing API Key"`)
+	}
+	if clientKey == initializers.AppConfig.ApiKey {
+		return c.Next()
+	}
+
+	// 1. Look the key up by hash
+	var key apikeys.APIKey
+	err := initializers.DB.Where("key_hash = ?", apikeys.Hash(clientKey)).First(&key).Error
+	if err != nil || !key.Active(clock.Now()) {
+		return c.Status(fiber.StatusForbidden).SendString(`"Invalid or missing API Key"`)
+	}
+
+	// 2. The coop in the path and the scope must be granted to the key
+	if ok, err := checkGrant(c, key.Coops, key.Scopes, "API key"); !ok {
+		return err
+	}
+
+	c.Locals("apiKey", &key)
+	return c.Next()
+}
+
+// BearerAuth validates an Authorization: Bearer access token from
+// /oauth/token: signature, expiry (virtual clock), the client key not being
+// revoked since, and the token's coops and scopes.
+func BearerAuth(c *fiber.Ctx) error {
+	token, ok := bearerToken(c)
+	if !ok {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="spic_to_erp"`)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":             "invalid_request",
+			"error_description": "missing bearer token",
+		})
+	}
+
+	// 1. Signature and expiry
+	claims, err := oauth.Parse(token, initializers.TokenSecret())
+	if err != nil {
+		return invalidToken(c, err.Error())
+	}
+
+	// 2. Tokens issued to a tenant key die with the key
+	if claims.KeyID != 0 {
+		var key apikeys.APIKey
+		if err := initializers.DB.First(&key, claims.KeyID).Error; err != nil || !key.Active(clock.Now()) {
+			return invalidToken(c, "client credentials have been revoked")
+		}
+	}
+
+	// 3. Coop and scope
+	if ok, err := checkGrant(c, claims.Coops, claims.Scopes(), "Access token"); !ok {
+		return err
+	}
 
-	if clientKey == "" || clientKey != expectedKey {
+	c.Locals("token", claims)
+	return c.Next()
+}
+
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func invalidToken(c *fiber.Ctx, description string) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token", error_description="`+description+`"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error":             "invalid_token",
+		"error_description": description,
+	})
+}
+
+// checkGrant enforces a tenant credential's coops and scopes on the request.
+// When it returns false the 403 has already been written.
+func checkGrant(c *fiber.Ctx, coops, scopes []string, credential string) (bool, error) {
+	if coopId := coopIDFromPath(c.Path()); coopId != "" && !apikeys.CoopAllowed(coops, coopId) {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "fail",
+			"message": credential + " is not permitted for cooperative " + coopId,
+		})
+	}
+
+	scope := apikeys.RequiredScope(c.Method(), c.Path())
+	if !apikeys.ScopeGranted(scopes, scope) {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "fail",
+			"message": credential + " lacks scope " + scope,
+		})
+	}
+	return true, nil
+}
+
+// AdminKeyAuth only lets the global APIKey through
+func AdminKeyAuth(c *fiber.Ctx) error {
+	clientKey := c.Get("APIKey")
+	if clientKey == "" || clientKey != initializers.AppConfig.ApiKey {
 		return c.Status(fiber.StatusForbidden).SendString(`"Invalid or missing API Key"`)
 	}
 	return c.Next()
 }
 
+// multipartPaths take photo uploads as multipart/form-data
+var multipartPaths = regexp.MustCompile(`/deliverydocuments/[^/]+/(proof|photos)/?$`)
+
 func JSONProviderMiddleware(c *fiber.Ctx) error {
 	contentType := c.Get("Content-Type")
 
@@ -26,6 +155,10 @@ func JSONProviderMiddleware(c *fiber.Ctx) error {
 		return c.Next()
 	}
 
+	if strings.HasPrefix(strings.ToLower(contentType), fiber.MIMEMultipartForm) && multipartPaths.MatchString(c.Path()) {
+		return c.Next()
+	}
+
 	// If header is present, it MUST contain application/json
 	// We use strings.Contains because some clients send "application/json; charset=utf-8"
 	if !strings.Contains(strings.ToLower(contentType), "application/json") {
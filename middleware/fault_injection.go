@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/models/faults"
+)
+
+// FaultInjection applies the first matching runtime fault rule (managed
+// through /admin/faults) so clients can exercise their error handling.
+func FaultInjection(c *fiber.Ctx) error {
+	rule, ok := faults.Pick(faults.Request{
+		Method: c.Method(),
+		Path:   c.Path(),
+		CoopID: coopIDFromPath(c.Path()),
+		Body:   c.Body(),
+	})
+	if !ok {
+		return c.Next()
+	}
+
+	log.Printf("💥 Fault rule %d hit: %s %s", rule.ID, c.Method(), c.Path())
+
+	// 1. Added latency
+	if rule.LatencyMs > 0 {
+		time.Sleep(time.Duration(rule.LatencyMs) * time.Millisecond)
+	}
+
+	// 2. Connection drop: close the socket without writing a response
+	if rule.Drop {
+		c.Context().HijackSetNoResponse(true)
+		c.Context().Hijack(func(conn net.Conn) {
+			conn.Close()
+		})
+		return nil
+	}
+
+	// 3. Fixed status, otherwise let the real handler answer
+	if rule.Status != 0 {
+		if rule.ResponseBody != "" {
+			c.Status(rule.Status).Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if err := c.SendString(rule.ResponseBody); err != nil {
+				return err
+			}
+		} else if err := c.Status(rule.Status).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Injected fault",
+		}); err != nil {
+			return err
+		}
+	} else if err := c.Next(); err != nil {
+		return err
+	}
+
+	// 4. Corrupt whatever body is going out
+	body := c.Response().Body()
+	switch rule.Body {
+	case faults.BodyTruncate:
+		c.Response().SetBody(append([]byte(nil), body[:len(body)/2]...))
+	case faults.BodyMalformed:
+		// Drop the closing bracket and leave a dangling comma
+		malformed := []byte(strings.TrimRight(string(body), "}] \n"))
+		c.Response().SetBody(append(malformed, ',', '"'))
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"log"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/journal"
+)
+
+// Bodies above this size are cut in the journal and flagged as truncated
+const maxJournalBody = 1 << 20
+
+var redactedHeaders = map[string]bool{
+	"apikey":        true,
+	"authorization": true,
+}
+
+// Journal records every request/response pair in initializers.Journal.
+// It sits first in the chain so auth failures and injected faults are
+// recorded as the client saw them.
+func Journal(c *fiber.Ctx) error {
+	start := time.Now()
+	at := clock.Now()
+
+	// Copy the request now; fasthttp reuses its buffers
+	reqHeaders := make(map[string]string)
+	c.Request().Header.VisitAll(func(k, v []byte) {
+		key := string(k)
+		if redactedHeaders[strings.ToLower(key)] {
+			reqHeaders[key] = "[REDACTED]"
+			return
+		}
+		reqHeaders[key] = string(v)
+	})
+	reqBody, reqTruncated := journalBody(c.Body())
+
+	// Let the error handler write the response so the real status is logged
+	if err := c.Next(); err != nil {
+		if herr := c.App().ErrorHandler(c, err); herr != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+
+	// When nothing matched, c.Route() is still this group's middleware
+	route, handler := c.Route().Path, handlerName(c.Route())
+	if strings.HasPrefix(handler, "middleware.") {
+		route, handler = "", ""
+	}
+
+	respHeaders := make(map[string]string)
+	c.Response().Header.VisitAll(func(k, v []byte) {
+		respHeaders[string(k)] = string(v)
+	})
+	respBody, respTruncated := journalBody(c.Response().Body())
+
+	entry := journal.Entry{
+		Time:            at.UTC(),
+		Method:          utils.CopyString(c.Method()),
+		Path:            utils.CopyString(c.Path()),
+		Query:           string(c.Request().URI().QueryString()),
+		Route:           route,
+		Handler:         handler,
+		CoopID:          utils.CopyString(coopIDFromPath(c.Path())),
+		RequestHeaders:  reqHeaders,
+		RequestBody:     reqBody,
+		Status:          c.Response().StatusCode(),
+		ResponseHeaders: respHeaders,
+		ResponseBody:    respBody,
+		LatencyMs:       float64(time.Since(start).Microseconds()) / 1000,
+		Truncated:       reqTruncated || respTruncated,
+	}
+
+	if c.Context().Hijacked() {
+		entry.Dropped = true
+		entry.Status = 0
+		entry.ResponseBody = ""
+		entry.ResponseHeaders = nil
+	}
+
+	if _, err := initializers.Journal.Record(entry); err != nil {
+		log.Println("journal error:", err)
+	}
+
+	return nil
+}
+
+func journalBody(b []byte) (string, bool) {
+	if len(b) > maxJournalBody {
+		return string(b[:maxJournalBody]), true
+	}
+	return string(b), false
+}
+
+// handlerName is the last handler of the matched route, e.g.
+// "controllers.CreateCustomerDetailHandler"
+func handlerName(route *fiber.Route) string {
+	if route == nil || len(route.Handlers) == 0 {
+		return ""
+	}
+
+	fn := runtime.FuncForPC(reflect.ValueOf(route.Handlers[len(route.Handlers)-1]).Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
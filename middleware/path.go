@@ -0,0 +1,14 @@
+package middleware
+
+import "strings"
+
+// coopIDFromPath returns the segment after customers/ or vendors/
+func coopIDFromPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(parts)-1; i++ {
+		if parts[i] == "customers" || parts[i] == "vendors" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/models/scenarios"
+	"github.com/shyamsundaar/karino-mock-server/models/stubs"
+)
+
+// Scenarios advances every scenario with a step matching the request and
+// answers with the first matching step's response override, if any;
+// otherwise the request continues to the real handler.
+func Scenarios(c *fiber.Ctx) error {
+	var list []scenarios.Scenario
+	if err := initializers.DB.Order("id").Find(&list).Error; err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return c.Next()
+	}
+
+	query := map[string]string{}
+	c.Request().URI().QueryArgs().VisitAll(func(k, v []byte) {
+		query[string(k)] = string(v)
+	})
+	headers := map[string]string{}
+	c.Request().Header.VisitAll(func(k, v []byte) {
+		headers[string(k)] = string(v)
+	})
+	req := stubs.NewRequest(utils.CopyString(c.Method()), c.OriginalURL(), query, headers, c.Body())
+
+	var override *scenarios.Response
+	var overrideParams map[string]string
+	for i := range list {
+		sc := &list[i]
+
+		step, ok := sc.Match(req.Method, c.Path(), req)
+		if !ok {
+			continue
+		}
+
+		if step.NextState != "" && step.NextState != sc.State {
+			// Only move from the state we matched in; a concurrent request may have won
+			res := initializers.DB.Model(&scenarios.Scenario{}).
+				Where("id = ? AND state = ?", sc.ID, sc.State).
+				Update("state", step.NextState)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				continue
+			}
+			log.Printf("🎬 Scenario %s: %s -> %s", sc.Name, sc.State, step.NextState)
+		}
+
+		if override == nil && step.Response != nil {
+			override = step.Response
+			overrideParams = req.Path
+		}
+	}
+
+	if override == nil {
+		return c.Next()
+	}
+
+	if override.DelayMs > 0 {
+		time.Sleep(time.Duration(override.DelayMs) * time.Millisecond)
+	}
+
+	if override.Drop {
+		c.Context().HijackSetNoResponse(true)
+		c.Context().Hijack(func(conn net.Conn) {
+			conn.Close()
+		})
+		return nil
+	}
+
+	req.Path = overrideParams
+	body, err := stubs.RenderTemplate(override.Body, req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Scenario response template failed: " + err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	for k, v := range override.Headers {
+		c.Set(k, v)
+	}
+	return c.Status(override.Status).SendString(body)
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/signing"
+)
+
+// SignatureVerification checks HMAC request signatures according to
+// SIGNATURE_MODE: "required" for every request, "coop" only for coops that
+// have their own signing secret, "off" (default) never. The coop's
+// signingSecret is used when set, SIGNATURE_SECRET otherwise.
+func SignatureVerification(c *fiber.Ctx) error {
+	mode := initializers.AppConfig.SignatureMode
+	if mode == "" || mode == initializers.SignatureModeOff {
+		return c.Next()
+	}
+
+	// 1. Pick the secret
+	coopId := coopIDFromPath(c.Path())
+	secret := initializers.AppConfig.SignatureSecret
+	coop, ok := initializers.LookupCooperative(coopId)
+	if ok && coop.SigningSecret != "" {
+		secret = coop.SigningSecret
+	} else if mode == initializers.SignatureModeCoop {
+		return c.Next()
+	}
+
+	if secret == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "No signing secret is configured for this cooperative",
+		})
+	}
+
+	// 2. Verify timestamp, signature and nonce. Clients sign with their wall
+	// clock, so the skew is checked against real time, not the virtual clock.
+	err := initializers.Signatures.Verify(signing.Signed{
+		Method:     c.Method(),
+		RequestURI: c.OriginalURL(),
+		Body:       c.Body(),
+		Signature:  c.Get(signing.HeaderSignature),
+		Timestamp:  c.Get(signing.HeaderTimestamp),
+		Nonce:      c.Get(signing.HeaderNonce),
+	}, secret, coopId, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "fail",
+			"message": "Invalid request signature: " + err.Error(),
+		})
+	}
+
+	return c.Next()
+}
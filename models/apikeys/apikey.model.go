@@ -0,0 +1,175 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Wildcards for Coops and Scopes
+const (
+	AllCoops  = "*"
+	AllScopes = "*"
+)
+
+// keyPrefix marks generated keys so they are easy to spot in logs and configs
+const keyPrefix = "kmk_"
+
+// APIKey is a tenant key for /spic_to_erp. Only the SHA-256 of the key is
+// stored; the plaintext is returned once, when the key is created.
+type APIKey struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string     `gorm:"size:255" json:"name" example:"spic-staging"`
+	Prefix    string     `gorm:"size:16;not null" json:"prefix" example:"kmk_1a2b3c4d"`
+	KeyHash   string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Coops     []string   `gorm:"type:json;serializer:json" json:"coops" example:"COOP019"`
+	Scopes    []string   `gorm:"type:json;serializer:json" json:"scopes" example:"farmers:write"`
+	ExpiresAt *time.Time `gorm:"default:null" json:"expiresAt"`
+	Revoked   bool       `gorm:"not null" json:"revoked"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// Generate returns a new random plaintext key
+func Generate() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return keyPrefix + hex.EncodeToString(buf), nil
+}
+
+// Hash is what gets stored and looked up for a plaintext key
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetKey stores the hash and display prefix of a plaintext key
+func (k *APIKey) SetKey(key string) {
+	k.KeyHash = Hash(key)
+	k.Prefix = key
+	if len(k.Prefix) > len(keyPrefix)+8 {
+		k.Prefix = k.Prefix[:len(keyPrefix)+8]
+	}
+}
+
+var scopePattern = regexp.MustCompile(`^([a-z]+:(read|write|\*)|\*)$`)
+
+// Validate checks the coop and scope lists
+func (k *APIKey) Validate() error {
+	if len(k.Coops) == 0 {
+		return fmt.Errorf("coops must list at least one coopId (or %q)", AllCoops)
+	}
+	if len(k.Scopes) == 0 {
+		return fmt.Errorf("scopes must list at least one scope, e.g. farmers:read")
+	}
+	for _, s := range k.Scopes {
+		if !scopePattern.MatchString(s) {
+			return fmt.Errorf("invalid scope %q (use resource:read, resource:write, resource:* or *)", s)
+		}
+	}
+	return nil
+}
+
+// Active reports whether the key can be used at now
+func (k *APIKey) Active(now time.Time) bool {
+	return !k.Revoked && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// AllowsCoop reports whether the key may act for coopId
+func (k *APIKey) AllowsCoop(coopId string) bool {
+	return CoopAllowed(k.Coops, coopId)
+}
+
+// CoopAllowed reports whether a coop list (possibly "*") covers coopId
+func CoopAllowed(coops []string, coopId string) bool {
+	for _, c := range coops {
+		if c == AllCoops || c == coopId {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeGranted reports whether a scope list covers scope, honouring
+// "resource:*" and "*"
+func ScopeGranted(scopes []string, scope string) bool {
+	resource, _, _ := strings.Cut(scope, ":")
+	for _, s := range scopes {
+		if s == AllScopes || s == scope || s == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredScope maps a /spic_to_erp request to the scope it needs:
+// GET/HEAD need <resource>:read, everything else <resource>:write.
+//
+//	/customers/:coopId/farmers...                         farmers
+//	/vendors/:coopId/farmers...                           farmers
+//	/customers/:coopId/salesorders...                     salesorders
+//	/customers/:coopId/salesorders/.../deliverydocuments  deliverydocuments
+//	/customers/:coopId/deliverydocuments/...              deliveryproofs
+//
+// Other paths (e.g. runtime stubs) use the segment after the coop, or the
+// first segment when there is no coop.
+func RequiredScope(method, path string) string {
+	action := "write"
+	if method == "GET" || method == "HEAD" {
+		action = "read"
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/spic_to_erp"), "/"), "/")
+	rest := parts
+	if len(parts) >= 2 && (parts[0] == "customers" || parts[0] == "vendors") {
+		rest = parts[2:]
+	}
+	if len(rest) == 0 || rest[0] == "" {
+		return parts[0] + ":" + action
+	}
+
+	resource := rest[0]
+	switch {
+	case resource == "salesorders" && contains(rest[1:], "deliverydocuments"):
+		resource = "deliverydocuments"
+	case resource == "deliverydocuments":
+		resource = "deliveryproofs"
+	}
+	return resource + ":" + action
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeySchema represents the admin create request body
+type CreateAPIKeySchema struct {
+	Name      string     `json:"name" example:"spic-staging"`
+	Coops     []string   `json:"coops" example:"COOP019"`
+	Scopes    []string   `json:"scopes" example:"farmers:read"`
+	ExpiresAt *time.Time `json:"expiresAt" example:"2027-01-01T00:00:00Z"`
+}
+
+// UpdateAPIKeySchema represents the admin update request body; omitted fields are kept
+type UpdateAPIKeySchema struct {
+	Name      *string    `json:"name" example:"spic-staging"`
+	Coops     []string   `json:"coops" example:"COOP019"`
+	Scopes    []string   `json:"scopes" example:"salesorders:write"`
+	ExpiresAt *time.Time `json:"expiresAt" example:"2027-01-01T00:00:00Z"`
+	Revoked   *bool      `json:"revoked" example:"true"`
+}
@@ -0,0 +1,96 @@
+package cooperatives
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Cooperative is a tenant allowed to call /spic_to_erp. Disabled
+// cooperatives are kept for their history but rejected like unknown ones.
+type Cooperative struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CoopID        string    `gorm:"size:64;not null;uniqueIndex" json:"coopId" example:"COOP019"`
+	Name          string    `gorm:"size:255" json:"name" example:"Karino Growers"`
+	Country       string    `gorm:"size:2" json:"country" example:"IN"`
+	Currency      string    `gorm:"size:3" json:"currency" example:"INR"`
+	TaxRate       float64   `json:"taxRate" example:"18"` // percent
+	Enabled       bool      `gorm:"not null" json:"enabled"`
+	SigningSecret string    `gorm:"size:255" json:"-"` // HMAC request signatures, see package signing
+	CreatedDate   time.Time `gorm:"autoCreateTime" json:"createdDate"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+
+	// HasSigningSecret is what responses show instead of the secret itself
+	HasSigningSecret bool `gorm:"-" json:"hasSigningSecret"`
+}
+
+func (Cooperative) TableName() string {
+	return "cooperatives"
+}
+
+func (c *Cooperative) AfterFind(tx *gorm.DB) error {
+	c.HasSigningSecret = c.SigningSecret != ""
+	return nil
+}
+
+func (c *Cooperative) AfterSave(tx *gorm.DB) error {
+	c.HasSigningSecret = c.SigningSecret != ""
+	return nil
+}
+
+var (
+	coopIDPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	countryPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// Normalize trims the fields and upper-cases the ISO codes
+func (c *Cooperative) Normalize() {
+	c.CoopID = strings.TrimSpace(c.CoopID)
+	c.Name = strings.TrimSpace(c.Name)
+	c.Country = strings.ToUpper(strings.TrimSpace(c.Country))
+	c.Currency = strings.ToUpper(strings.TrimSpace(c.Currency))
+}
+
+// Validate checks a cooperative before it is stored
+func (c *Cooperative) Validate() error {
+	if !coopIDPattern.MatchString(c.CoopID) {
+		return fmt.Errorf("coopId must only contain letters, digits, '_' and '-'")
+	}
+	if c.Country != "" && !countryPattern.MatchString(c.Country) {
+		return fmt.Errorf("country must be an ISO 3166-1 alpha-2 code")
+	}
+	if c.Currency != "" && !currencyPattern.MatchString(c.Currency) {
+		return fmt.Errorf("currency must be an ISO 4217 code")
+	}
+	if c.TaxRate < 0 || c.TaxRate > 100 {
+		return fmt.Errorf("taxRate must be a percentage between 0 and 100")
+	}
+	return nil
+}
+
+// CreateCooperativeSchema represents the admin create request body
+type CreateCooperativeSchema struct {
+	CoopID   string  `json:"coopId" example:"COOP019"`
+	Name     string  `json:"name" example:"Karino Growers"`
+	Country  string  `json:"country" example:"IN"`
+	Currency string  `json:"currency" example:"INR"`
+	TaxRate  float64 `json:"taxRate" example:"18"`
+	Enabled  *bool   `json:"enabled" example:"true"` // default true
+
+	SigningSecret string `json:"signingSecret" example:"s3cr3t"`
+}
+
+// UpdateCooperativeSchema represents the admin update request body; omitted fields are kept
+type UpdateCooperativeSchema struct {
+	Name     *string  `json:"name" example:"Karino Growers"`
+	Country  *string  `json:"country" example:"IN"`
+	Currency *string  `json:"currency" example:"INR"`
+	TaxRate  *float64 `json:"taxRate" example:"18"`
+	Enabled  *bool    `json:"enabled" example:"false"`
+
+	SigningSecret *string `json:"signingSecret" example:"s3cr3t"`
+}
@@ -3,6 +3,7 @@ package delivery
 import (
 	"time"
 
+	"github.com/shyamsundaar/karino-mock-server/clock"
 	"gorm.io/gorm"
 )
 
@@ -12,14 +13,23 @@ type CreateDeliveryDocuments struct {
 	ErpSalesOrderCode    string     `gorm:"column:erp_sales_order_code;size:64" json:"erp_sales_order_code"`
 	OrderID              string     `json:"order_id" gorm:"size:64;index;not null"`
 	DeliveryDocumentID   string     `json:"delivery_document_id" gorm:"size:64;index;not null"`
-	DeliveryDocumentCode string     `json:"delivery_document_code" gorm:"size:64;index;not null"`
-	OrderItemID          string     `json:"order_item_id" gorm:"size:64;index;not null"`
+	DeliveryDocumentCode string     `json:"delivery_document_code" gorm:"size:64;index;not null;uniqueIndex:idx_delivery_document_item"`
+	OrderItemID          string     `json:"order_item_id" gorm:"size:64;index;not null;uniqueIndex:idx_delivery_document_item"`
 	StockKeppingUnit     string     `json:"stock_keeping_unit" gorm:"size:64;index;not null"`
+	Quantity             float64    `json:"quantity" gorm:"not null;default:0"` // delivered on this document
 	CreatedAt            *time.Time `json:"created_at"`
 	UpdatedAt            *time.Time `json:"updated_at"`
 	IdCreatedAt			 *time.Time `json:"id_created_at"`
 	ExpirationTime       *time.Time `json:"expires_at"`
 	Status				 string 	`json:"status"`
+
+	// A reissued document points at the expired one it replaces, which is
+	// VOIDED and points back at it
+	PreviousDeliveryDocumentID     string     `json:"previous_delivery_document_id" gorm:"size:64"`
+	PreviousDeliveryDocumentCode   string     `json:"previous_delivery_document_code" gorm:"size:64"`
+	ReplacedByDeliveryDocumentID   string     `json:"replaced_by_delivery_document_id" gorm:"size:64"`
+	ReplacedByDeliveryDocumentCode string     `json:"replaced_by_delivery_document_code" gorm:"size:64"`
+	VoidedAt                       *time.Time `json:"voided_at"`
 }
 
 func (CreateDeliveryDocuments) TableName() string {
@@ -28,16 +38,47 @@ func (CreateDeliveryDocuments) TableName() string {
 
 // BeforeCreate Hook to handle any logic before saving to DB
 func (d *CreateDeliveryDocuments) BeforeCreate(tx *gorm.DB) (err error) {
-	var now = time.Now()
+	var now = clock.Now()
 	d.CreatedAt = &now
 	d.UpdatedAt = &now
 	return nil
 }
 
+// CreateDeliveryDocumentSchema either splits the open items of an order
+// into NoofDeliveryDocuments documents, or delivers the quantities listed
+// per document in DeliveryDocuments
 type CreateDeliveryDocumentSchema struct {
 	ErpSalesOrderCode     string `gorm:"column:erp_sales_order_code;size:64" json:"erp_sales_order_code"`
 	OrderID               string `json:"order_id" gorm:"size:64;index;not null"`
 	NoofDeliveryDocuments int    `json:"no_of_delivery_documents"`
+
+	DeliveryDocuments []DeliveryDocumentRequest `json:"delivery_documents"`
+}
+
+type DeliveryDocumentRequest struct {
+	Items []DeliveryDocumentItemRequest `json:"items"`
+}
+
+type DeliveryDocumentItemRequest struct {
+	OrderItemID string  `json:"order_item_id"`
+	Quantity    float64 `json:"quantity"`
+}
+
+type VoidDeliveryDocumentResponse struct {
+	ERPDeliveryDocumentId   string    `json:"erpDeliveryDocumentId"`
+	ERPDeliveryDocumentCode string    `json:"erpDeliveryDocumentCode"`
+	Status                  string    `json:"status"`
+	VoidedAt                time.Time `json:"voidedAt"`
+}
+
+type ReissueDeliveryDocumentResponse struct {
+	ERPDeliveryDocumentId           string    `json:"erpDeliveryDocumentId"`
+	ERPDeliveryDocumentCode         string    `json:"erpDeliveryDocumentCode"`
+	ERPDeliveryDocumentDate         time.Time `json:"erpDeliveryDocumentDate"`
+	ExpiresAt                       time.Time `json:"expiresAt"`
+	Status                          string    `json:"status"`
+	PreviousERPDeliveryDocumentId   string    `json:"previousErpDeliveryDocumentId"`
+	PreviousERPDeliveryDocumentCode string    `json:"previousErpDeliveryDocumentCode"`
 }
 
 type CreateDeliveryDocumentSuccessResponse struct {
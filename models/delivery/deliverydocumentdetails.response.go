@@ -3,20 +3,39 @@ package delivery
 import "time"
 
 type DeliveryNotesResponse struct {
-	DeliveryNotes []DeliveryNote `json:"deliveryNotes"`
+	DeliveryNotes  []DeliveryNote      `json:"deliveryNotes"`
+	OrderItems     []DeliveryOrderItem `json:"orderItems"`
+	FullyDelivered bool                `json:"fullyDelivered"`
+}
+
+// DeliveryOrderItem is the delivery progress of one sales order item
+type DeliveryOrderItem struct {
+	OrderItemID       string  `json:"order_item_id"`
+	ERPItemID         string  `json:"erpItemID"`
+	Quantity          float64 `json:"quantity"`
+	DeliveredQuantity float64 `json:"deliveredQuantity"`
+	OpenQuantity      float64 `json:"openQuantity"`
 }
 
 type DeliveryNote struct {
 	ERPDeliveryDocumentId   string    `json:"erpDeliveryDocumentId"`
 	ERPDeliveryDocumentCode string    `json:"erpDeliveryDocumentCode"`
 	ERPDeliveryDocumentDate time.Time `json:"erpDeliveryDocumentDate"`
+	Status                  string    `json:"status"`
+
+	PreviousERPDeliveryDocumentId     string `json:"previousErpDeliveryDocumentId,omitempty"`
+	PreviousERPDeliveryDocumentCode   string `json:"previousErpDeliveryDocumentCode,omitempty"`
+	ReplacedByERPDeliveryDocumentId   string `json:"replacedByErpDeliveryDocumentId,omitempty"`
+	ReplacedByERPDeliveryDocumentCode string `json:"replacedByErpDeliveryDocumentCode,omitempty"`
+
 	Items                   []DeliveryItem `json:"items"`
 }
 
 type DeliveryItem struct {
 	ERPItemID2          string            `json:"erpItemID"`
 	StockKeepingUnit   string            `json:"stock_keeping_unit"`
-	Quantity           float64           `json:"quantity"`
+	Quantity           float64           `json:"quantity"` // delivered on this document
+	OpenQuantity       float64           `json:"openQuantity"`
 	SalesOrder         DeliverySalesOrder `json:"salesOrder"`
 }
 
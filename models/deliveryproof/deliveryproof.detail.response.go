@@ -8,6 +8,10 @@ type Invoice struct {
 	ERPInvoiceId   string        `json:"erpInvoiceId"`
 	ERPInvoiceCode string        `json:"erpInvoiceCode"`
 	ERPInvoiceDate string        `json:"erpInvoiceDate"`
+	Currency       string        `json:"currency"`
+	NetAmount      float64       `json:"netAmount"`
+	TaxAmount      float64       `json:"taxAmount"`
+	TotalAmount    float64       `json:"totalAmount"`
 	Items          []InvoiceItem `json:"items"`
 }
 
@@ -15,6 +19,10 @@ type InvoiceItem struct {
 	ERPItemID         string             `json:"erpItemID"`
 	StockKeepingUnit  string             `json:"stock_keeping_unit"`
 	Quantity          float64            `json:"quantity"`
+	UnitPrice         float64            `json:"unitPrice"`
+	TaxRate           float64            `json:"taxRate"`
+	NetAmount         float64            `json:"netAmount"`
+	TaxAmount         float64            `json:"taxAmount"`
 	DeliveryNote      InvoiceDeliveryNote `json:"deliveryNote"`
 }
 
@@ -3,6 +3,10 @@ package deliveryproof
 import (
 	"time"
 	"strconv"
+
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/models/photos"
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
 	"gorm.io/gorm"
 )
 
@@ -66,9 +70,9 @@ type Waybill struct {
 	ID         uint   `gorm:"primaryKey;autoIncrement"`
 	ContractID string `gorm:"size:128"`
 	CoopID     string `gorm:"column:coop_id;not null" json:"coopId"`
-	TempID string `gorm:"column:temp_id;not null" json:"temp_id"`
-	// OrderID must be a string and unique to be used as a reference
-	OrderID              string `gorm:"column:order_id;size:64;uniqueIndex" json:"order_id"`
+	TempID string `gorm:"column:temp_id;size:64;not null;uniqueIndex" json:"temp_id"`
+	// An order has one waybill per delivery document
+	OrderID              string `gorm:"column:order_id;size:64;index:idx_way_bill_order" json:"order_id"`
 	RegionID             int    `json:"region_id"`
 	RegionPartID         int    `json:"region_part_id"`
 	SettlementID         int    `json:"settlement_id"`
@@ -81,10 +85,10 @@ type Waybill struct {
 	DeliveryNoteID       string `gorm:"size:128" json:"deliveryNoteId"`
 	DeliveryNoteDocument string `gorm:"type:text" json:"deliveryNoteDocument"`
 
-	// Relationship: Link WaybillItem.OrderID to Waybill.OrderID
-	Items []WaybillItem `gorm:"foreignKey:OrderID;references:OrderID" json:"items"`
+	// Relationship: Link WaybillItem.WaybillID to Waybill.ID
+	Items []WaybillItem `gorm:"foreignKey:WaybillID" json:"items"`
 
-	DeliveryPhotos string `gorm:"type:json" json:"deliveryPhotos"`
+	DeliveryPhotos []photos.Photo `gorm:"type:json;serializer:json" json:"deliveryPhotos"`
 
 	CreatedAt time.Time      `gorm:"default:null"`
 	UpdatedAt time.Time      `gorm:"default:null"`
@@ -96,8 +100,8 @@ func (Waybill) TableName() string {
 }
 
 type WaybillItem struct {
-	ID uint `gorm:"primaryKey;autoIncrement"`
-	// This MUST be string to match Waybill.OrderID
+	ID        uint `gorm:"primaryKey;autoIncrement"`
+	WaybillID uint `gorm:"index;default:null" json:"waybill_id"`
 	OrderID         string  `gorm:"column:order_id;size:64;index;not null" json:"order_id"`
 	Name            string  `gorm:"size:255"`
 	NumberOfUnits   int     `json:"number_of_units"`
@@ -118,29 +122,15 @@ func (WaybillItem) TableName() string {
 }
 
 func (d *Waybill) BeforeCreate(tx *gorm.DB) (err error) {
-	now := time.Now()
-
-	// Fetch last TempID
-	var lastTempID string
-
-	err = tx.
-		Model(&Waybill{}).
-		Select("temp_id").
-		Where("temp_id IS NOT NULL AND temp_id != ''").
-		Order("id DESC").
-		Limit(1).
-		Scan(&lastTempID).Error
-
-	// Default start value
-	next := 1000
-
-	if err == nil && lastTempID != "" {
-		if n, convErr := strconv.Atoi(lastTempID); convErr == nil {
-			next = n + 1
-		}
+	now := clock.Now()
+
+	// Allocate TempID from the shared counter (starts at 1000)
+	next, err := sequences.Next(tx, sequences.WaybillTempID, 1000)
+	if err != nil {
+		return err
 	}
 
-	d.TempID = strconv.Itoa(next)
+	d.TempID = strconv.FormatInt(next, 10)
 	d.CreatedAt = now
 	d.UpdatedAt = now
 
@@ -191,4 +181,5 @@ type CreateDocumentdeliveryProofResponse struct {
 	TempERPProofId string `json:"tempERPProofId"`
 	OrderId        string `json:"orderId"`
 	Message        string `json:"Message"`
+	Photos         []photos.Photo `json:"photos"`
 }
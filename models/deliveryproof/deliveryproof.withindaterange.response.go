@@ -3,6 +3,9 @@ package deliveryproof
 type DocumentdeliveryProof struct{
 	ERPDeliveryDocumentId string `json:"erpDeliveryDocumentId"`
     ERPDeliveryDocumentCode string `json:"erpDeliveryDocumentCode"`
+	ERPInvoiceId string `json:"erpInvoiceId"`
+	ERPInvoiceCode string `json:"erpInvoiceCode"`
+	ERPInvoiceDate string `json:"erpInvoiceDate"`
 }
 
 type ListDeliveryDocumentsResponse struct {
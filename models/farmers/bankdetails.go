@@ -0,0 +1,101 @@
+package models
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrIBANRequired = errors.New("You must provide the IBAN with the bank details.")
+	ErrIBANInvalid  = errors.New("The indicated IBAN is not valid.")
+	ErrBICInvalid   = errors.New("The indicated SWIFT/BIC code is not valid.")
+)
+
+// ibanLengths is the IBAN length of every country in the SWIFT IBAN registry
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BI": 27, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24,
+	"DE": 22, "DJ": 27, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27,
+	"GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27,
+	"JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20,
+	"LV": 21, "LY": 25, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20, "MR": 27,
+	"MT": 31, "MU": 30, "NI": 28, "NL": 18, "NO": 15, "OM": 23, "PK": 24, "PL": 28,
+	"PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33, "SA": 24, "SC": 31,
+	"SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25, "SV": 28,
+	"TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20, "YE": 30,
+}
+
+var (
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]+$`)
+	// 4 letters bank, 2 letters country, 2 location, optional 3 branch
+	bicPattern = regexp.MustCompile(`^[A-Z]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+)
+
+// ValidIBAN checks the country length and the ISO 13616 mod-97 checksum
+func ValidIBAN(iban string) bool {
+	if !ibanPattern.MatchString(iban) {
+		return false
+	}
+	if length, ok := ibanLengths[iban[:2]]; !ok || len(iban) != length {
+		return false
+	}
+
+	// Move the first four characters to the end and turn letters into 10..35
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(big.NewInt(int64(r-'A') + 10).String())
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// ValidBIC checks the ISO 9362 format of a SWIFT/BIC code
+func ValidBIC(bic string) bool {
+	return bicPattern.MatchString(bic)
+}
+
+// Normalize drops the spaces of the printed form and upper-cases the codes
+func (b *BankDetailsInfo) Normalize() {
+	clean := func(s string) string {
+		return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	}
+	b.IBAN = clean(b.IBAN)
+	b.SWIFT = clean(b.SWIFT)
+}
+
+// Validate checks bank details sent by a vendor. Both fields empty clears
+// them; the SWIFT/BIC code is optional.
+func (b *BankDetailsInfo) Validate() error {
+	if b.IBAN == "" && b.SWIFT == "" {
+		return nil
+	}
+	if b.IBAN == "" {
+		return ErrIBANRequired
+	}
+	if !ValidIBAN(b.IBAN) {
+		return ErrIBANInvalid
+	}
+	if b.SWIFT != "" && !ValidBIC(b.SWIFT) {
+		return ErrBICInvalid
+	}
+	return nil
+}
+
+// SetBankDetails stores validated bank details on the farmer
+func (d *FarmerDetails) SetBankDetails(b BankDetailsInfo) {
+	d.BankIBAN = b.IBAN
+	d.BankSWIFT = b.SWIFT
+}
+
+// BankDetails returns the farmer's bank details as sent to the ERP
+func (d *FarmerDetails) BankDetails() BankDetailsInfo {
+	return BankDetailsInfo{IBAN: d.BankIBAN, SWIFT: d.BankSWIFT}
+}
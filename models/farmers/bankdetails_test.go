@@ -0,0 +1,79 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidIBAN(t *testing.T) {
+	tests := []struct {
+		iban string
+		want bool
+	}{
+		{"GB82WEST12345698765432", true},
+		{"DE89370400440532013000", true},
+		{"NL91ABNA0417164300", true},
+		{"FR1420041010050500013M02606", true},
+		{"NO9386011117947", true},
+		{"GB82WEST12345698765433", false}, // checksum
+		{"GB82WEST1234569876543", false},  // length for GB
+		{"XX82WEST12345698765432", false}, // unknown country
+		{"gb82WEST12345698765432", false}, // not normalized
+		{"GB82 WEST 1234 5698 7654 32", false},
+		{"GBXXWEST12345698765432", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidIBAN(tt.iban); got != tt.want {
+			t.Errorf("ValidIBAN(%q) = %v, want %v", tt.iban, got, tt.want)
+		}
+	}
+}
+
+func TestValidBIC(t *testing.T) {
+	tests := []struct {
+		bic  string
+		want bool
+	}{
+		{"DEUTDEFF", true},
+		{"DEUTDEFF500", true},
+		{"NEDSZAJJXXX", true},
+		{"DEUTDEF", false},
+		{"DEUTDEFF50", false},
+		{"DEU1DEFF", false},
+		{"deutdeff", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidBIC(tt.bic); got != tt.want {
+			t.Errorf("ValidBIC(%q) = %v, want %v", tt.bic, got, tt.want)
+		}
+	}
+}
+
+func TestBankDetailsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		details BankDetailsInfo
+		want    error
+	}{
+		{"empty clears", BankDetailsInfo{}, nil},
+		{"printed form", BankDetailsInfo{IBAN: "gb82 west 1234 5698 7654 32", SWIFT: "deut de ff"}, nil},
+		{"BIC is optional", BankDetailsInfo{IBAN: "DE89370400440532013000"}, nil},
+		{"BIC without IBAN", BankDetailsInfo{SWIFT: "DEUTDEFF"}, ErrIBANRequired},
+		{"bad IBAN", BankDetailsInfo{IBAN: "DE89370400440532013001"}, ErrIBANInvalid},
+		{"bad BIC", BankDetailsInfo{IBAN: "DE89370400440532013000", SWIFT: "DEUT"}, ErrBICInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := tt.details
+			details.Normalize()
+			if err := details.Validate(); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -20,11 +20,12 @@ type FarmerDetailResponse struct {
 	VendorCode         string          `json:"VendorCode"`
 	CreatedDate        string          `json:"CreatedDate"`
 	UpdatedDate        string          `json:"UpdatedDate"`
+	Active             bool            `json:"Active"`
 	BankDetails        BankDetailsInfo `json:"BankDetails"`
 }
 
 // Nested object
 type BankDetailsInfo struct {
-	IBAN  string `json:"IBAN"`
-	SWIFT string `json:"SWIFT"`
+	IBAN  string `json:"IBAN" example:"DE89370400440532013000"`
+	SWIFT string `json:"SWIFT" example:"COBADEFFXXX"`
 }
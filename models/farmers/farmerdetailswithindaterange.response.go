@@ -7,6 +7,7 @@ type FarmerCustomerResponse struct {
 	FarmerId          string `json:"farmerId"`
 	CreatedAt         string `json:"createdAt"`
 	UpdatedAt         string `json:"updatedAt"`
+	Active            bool   `json:"active"`
 	// Message           string `json:"message"`
 }
 
@@ -17,6 +18,7 @@ type FarmerVendorResponse struct {
 	FarmerId          string `json:"farmerId"`
 	CreatedAt         string `json:"createdAt"`
 	UpdatedAt         string `json:"updatedAt"`
+	Active            bool   `json:"active"`
 	// Message           string `json:"message"`
 }
 
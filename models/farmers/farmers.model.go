@@ -6,16 +6,19 @@ import (
 	"github.com/go-playground/validator/v10"
 	// "github.com/google/uuid"
 	"strconv"
+
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
 	"gorm.io/gorm"
 )
 
 // Detail represents the 'details' table in the database
 type FarmerDetails struct {
 	ID                          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
-	TempID                      string     `gorm:"not null" json:"tempId"`
+	TempID                      string     `gorm:"size:64;not null;uniqueIndex" json:"tempId"`
 	CoopID                      string     `gorm:"not null" json:"coopId"`
-	CustomerID                  string     `json:"customerId"`
-	VendorID                    string     `json:"vendorId"`
+	CustomerID                  string     `gorm:"size:64;uniqueIndex;default:null" json:"customerId"`
+	VendorID                    string     `gorm:"size:64;uniqueIndex;default:null" json:"vendorId"`
 	FarmerID                    string     `gorm:"not null" json:"farmerId"`
 	FirstName                   string     `gorm:"not null" json:"firstName"`
 	LastName                    string     `gorm:"not null" json:"lastName"`
@@ -39,33 +42,24 @@ type FarmerDetails struct {
 	UpdatedAt                   time.Time  `gorm:"default:null"`
 	CustIDUpdateAt              *time.Time `gorm:"default:null"`
 	VendorIDUpdateAt            *time.Time `gorm:"default:null"`
+	Active                      bool       `gorm:"not null;default:true" json:"active"`
+	DeactivatedAt               *time.Time `gorm:"default:null" json:"deactivatedAt"`
+	DetailsUpdateAt             *time.Time `gorm:"default:null" json:"-"` // last PATCH/deactivate/reactivate
+	BankIBAN                    string     `gorm:"size:34" json:"bankIban"`
+	BankSWIFT                   string     `gorm:"size:11" json:"bankSwift"`
 }
 
 // BeforeCreate Hook to handle any logic before saving to DB
 func (d *FarmerDetails) BeforeCreate(tx *gorm.DB) (err error) {
-	now := time.Now()
-
-	// Fetch last TempID
-	var lastTempID string
-
-	err = tx.
-		Model(&FarmerDetails{}).
-		Select("temp_id").
-		Where("temp_id IS NOT NULL AND temp_id != ''").
-		Order("id DESC").
-		Limit(1).
-		Scan(&lastTempID).Error
-
-	// Default start value
-	next := 1000
+	now := clock.Now()
 
-	if err == nil && lastTempID != "" {
-		if n, convErr := strconv.Atoi(lastTempID); convErr == nil {
-			next = n + 1
-		}
+	// Allocate TempID from the shared counter (starts at 1000)
+	next, err := sequences.Next(tx, sequences.FarmerTempID, 1000)
+	if err != nil {
+		return err
 	}
 
-	d.TempID = strconv.Itoa(next)
+	d.TempID = strconv.FormatInt(next, 10)
 	d.CreatedAt = now
 	d.UpdatedAt = now
 
@@ -128,4 +122,68 @@ type CreateDetailSchema struct {
 	ClubLeaderFarmerID string `json:"clubLeaderFarmerId" example:"string"`
 	RaithuCreatedDate  string `json:"createdDate" example:"2025-12-30T05:03:17.863Z"`
 	RaithuUpdatedAt    string `json:"updatedAt" example:"2025-12-30T05:03:17.863Z"`
+
+	// Vendors only; see BankDetailsInfo.Validate
+	BankDetails *BankDetailsInfo `json:"bankDetails"`
+}
+
+// UpdateDetailSchema is the PATCH body for a farmer; only the fields present
+// in the request are changed, the farmerId itself is immutable
+// swagger:model UpdateDetailSchema
+type UpdateDetailSchema struct {
+	FirstName          *string `json:"firstName" example:"string"`
+	LastName           *string `json:"lastName" example:"string"`
+	MobileNumber       *string `json:"mobile_number" example:"string"`
+	RegionID           *int    `json:"regionId" example:"0"`
+	RegionPartID       *int    `json:"regionPartID" example:"0"`
+	SettlementID       *int    `json:"settlementID" example:"0"`
+	SettlementPartID   *int    `json:"settlementPartID" example:"0"`
+	CustomGeo1ID       *int    `json:"custom_geography_structure1_id" example:"0"`
+	CustomGeo2ID       *int    `json:"custom_geography_structure2_id" example:"0"`
+	ZipCode            *string `json:"ZipCode" example:"string"`
+	FarmerKycTypeID    *int    `json:"farmer_kyc_type_id" example:"0"`
+	FarmerKycType      *string `json:"farmer_kyc_type" example:"string"`
+	FarmerKycID        *string `json:"farmer_kyc_id" example:"string"`
+	ClubID             *string `json:"clubId" example:"string"`
+	ClubName           *string `json:"clubName" example:"string"`
+	ClubLeaderFarmerID *string `json:"clubLeaderFarmerId" example:"string"`
+	RaithuUpdatedAt    *string `json:"updatedAt" example:"2025-12-30T05:03:17.863Z"`
+
+	// Vendors only; {"IBAN": "", "SWIFT": ""} clears them
+	BankDetails *BankDetailsInfo `json:"bankDetails"`
+}
+
+// Apply copies the fields present in the patch onto d
+func (p *UpdateDetailSchema) Apply(d *FarmerDetails) {
+	setString := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	setInt := func(dst *int, src *int) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+
+	setString(&d.FirstName, p.FirstName)
+	setString(&d.LastName, p.LastName)
+	setString(&d.MobileNumber, p.MobileNumber)
+	setInt(&d.RegionID, p.RegionID)
+	setInt(&d.RegionPartID, p.RegionPartID)
+	setInt(&d.SettlementID, p.SettlementID)
+	setInt(&d.SettlementPartID, p.SettlementPartID)
+	setInt(&d.CustomGeographyStructure1ID, p.CustomGeo1ID)
+	setInt(&d.CustomGeographyStructure2ID, p.CustomGeo2ID)
+	setString(&d.ZipCode, p.ZipCode)
+	setInt(&d.FarmerKycTypeID, p.FarmerKycTypeID)
+	setString(&d.FarmerKycType, p.FarmerKycType)
+	setString(&d.FarmerKycID, p.FarmerKycID)
+	setString(&d.ClubID, p.ClubID)
+	setString(&d.ClubName, p.ClubName)
+	setString(&d.ClubLeaderFarmerID, p.ClubLeaderFarmerID)
+	setString(&d.RaithuUpdatedAt, p.RaithuUpdatedAt)
+	if p.BankDetails != nil {
+		d.SetBankDetails(*p.BankDetails)
+	}
 }
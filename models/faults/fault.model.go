@@ -0,0 +1,223 @@
+package faults
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+// Body corruption modes
+const (
+	BodyTruncate  = "truncate"
+	BodyMalformed = "malformed"
+)
+
+// Rule describes a fault injected into matching /spic_to_erp requests.
+// Empty match fields match anything; the first matching rule (by ID) wins.
+type Rule struct {
+	ID uint `json:"id"`
+
+	// Match
+	Method    string            `json:"method" example:"POST"`
+	Route     string            `json:"route" example:"/spic_to_erp/customers/:coopId/farmers"`
+	CoopID    string            `json:"coopId" example:"COOP019"`
+	BodyMatch map[string]string `json:"bodyMatch"`
+
+	// Probability in [0,1] that a matching request is faulted (default 1)
+	Rate *float64 `json:"rate" example:"0.5"`
+
+	// Fault
+	LatencyMs    int    `json:"latencyMs" example:"2000"`
+	Status       int    `json:"status" example:"503"`
+	ResponseBody string `json:"responseBody"`
+	Drop         bool   `json:"drop"`
+	Body         string `json:"body" example:"truncate"`
+
+	// Stop injecting after this many hits (0 = unlimited)
+	MaxHits int `json:"maxHits"`
+	Hits    int `json:"hits"`
+}
+
+// Validate checks a rule before it is registered
+func (r *Rule) Validate() error {
+	if r.Rate != nil && (*r.Rate < 0 || *r.Rate > 1) {
+		return fmt.Errorf("rate must be between 0 and 1")
+	}
+	if r.Status != 0 && (r.Status < 100 || r.Status > 599) {
+		return fmt.Errorf("status must be a valid HTTP status code")
+	}
+	if r.Body != "" && r.Body != BodyTruncate && r.Body != BodyMalformed {
+		return fmt.Errorf("body must be %q or %q", BodyTruncate, BodyMalformed)
+	}
+	if r.LatencyMs < 0 || r.MaxHits < 0 {
+		return fmt.Errorf("latencyMs and maxHits must not be negative")
+	}
+	if r.LatencyMs == 0 && r.Status == 0 && !r.Drop && r.Body == "" {
+		return fmt.Errorf("rule needs at least one of latencyMs, status, drop or body")
+	}
+	return nil
+}
+
+// Request is what a rule is matched against
+type Request struct {
+	Method string
+	Path   string
+	CoopID string
+	Body   []byte
+}
+
+func (r *Rule) matches(req Request) bool {
+	if r.MaxHits > 0 && r.Hits >= r.MaxHits {
+		return false
+	}
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return false
+	}
+	if r.Route != "" && !MatchRoute(r.Route, req.Path) {
+		return false
+	}
+	if r.CoopID != "" && r.CoopID != req.CoopID {
+		return false
+	}
+	if len(r.BodyMatch) > 0 && !MatchBody(r.BodyMatch, req.Body) {
+		return false
+	}
+	return true
+}
+
+// MatchRoute matches a path against a Fiber-style pattern: ":name"
+// matches one segment and a trailing "*" matches the rest.
+func MatchRoute(pattern, path string) bool {
+	pp := strings.Split(strings.Trim(pattern, "/"), "/")
+	sp := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, seg := range pp {
+		if seg == "*" {
+			return true
+		}
+		if i >= len(sp) {
+			return false
+		}
+		if strings.HasPrefix(seg, ":") {
+			continue
+		}
+		if seg != sp[i] {
+			return false
+		}
+	}
+	return len(pp) == len(sp)
+}
+
+// MatchBody reports whether every dotted field path in want (e.g.
+// "waybill.order_id") is present in the JSON body with the given value.
+func MatchBody(want map[string]string, body []byte) bool {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return false
+	}
+
+	for path, expected := range want {
+		v := doc
+		for _, key := range strings.Split(path, ".") {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			if v, ok = obj[key]; !ok {
+				return false
+			}
+		}
+		if fmt.Sprint(v) != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// =======================
+// Runtime registry
+// =======================
+
+var (
+	mu     sync.Mutex
+	rules  []*Rule
+	nextID uint = 1
+)
+
+// Add registers a rule and returns it with its ID
+func Add(r Rule) Rule {
+	mu.Lock()
+	defer mu.Unlock()
+
+	r.ID = nextID
+	r.Hits = 0
+	nextID++
+	rules = append(rules, &r)
+	return r
+}
+
+// List returns a copy of all rules
+func List() []Rule {
+	mu.Lock()
+	defer mu.Unlock()
+
+	out := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, *r)
+	}
+	return out
+}
+
+// Get returns the rule with the given ID
+func Get(id uint) (Rule, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, r := range rules {
+		if r.ID == id {
+			return *r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Delete removes a rule; it reports whether the rule existed
+func Delete(id uint) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, r := range rules {
+		if r.ID == id {
+			rules = append(rules[:i], rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes all rules
+func Clear() {
+	mu.Lock()
+	defer mu.Unlock()
+	rules = nil
+}
+
+// Pick returns the fault to inject for req, if any, and counts the hit
+func Pick(req Request) (Rule, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, r := range rules {
+		if !r.matches(req) {
+			continue
+		}
+		if r.Rate != nil && rand.Float64() >= *r.Rate {
+			continue
+		}
+		r.Hits++
+		return *r, true
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,86 @@
+package invoices
+
+import (
+	"math"
+	"time"
+)
+
+// Invoice statuses. An invoice is PENDING from the delivery proof until the
+// invoice job assigns its ERP ID, code and date (INVOICE_TIME_SECONDS).
+const (
+	StatusPending = "PENDING"
+	StatusIssued  = "ISSUED"
+)
+
+// Invoice bills the items of one delivery proof (waybill) of a delivery document
+type Invoice struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	CoopID    string `gorm:"size:64;not null;index" json:"coopId"`
+	WaybillID uint   `gorm:"not null;index" json:"waybillId"`
+	OrderID   string `gorm:"size:64;not null;index" json:"orderId"`
+
+	DeliveryDocumentID   string     `gorm:"size:64;index" json:"deliveryDocumentId"`
+	DeliveryDocumentCode string     `gorm:"size:64" json:"deliveryDocumentCode"`
+	DeliveryDocumentDate *time.Time `gorm:"default:null" json:"deliveryDocumentDate"`
+
+	ErpInvoiceID   string     `gorm:"size:64;uniqueIndex;default:null" json:"erpInvoiceId"`
+	ErpInvoiceCode string     `gorm:"size:64;uniqueIndex;default:null" json:"erpInvoiceCode"`
+	InvoiceDate    *time.Time `gorm:"default:null" json:"invoiceDate"`
+	Status         string     `gorm:"size:16;not null;index" json:"status"`
+
+	Currency    string  `gorm:"size:3" json:"currency"`
+	NetAmount   float64 `json:"netAmount"`
+	TaxAmount   float64 `json:"taxAmount"`
+	TotalAmount float64 `json:"totalAmount"`
+
+	Lines []InvoiceLine `gorm:"foreignKey:InvoiceID" json:"lines"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// InvoiceLine is one delivered item, priced like its sales order item
+type InvoiceLine struct {
+	ID        uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	InvoiceID uint `gorm:"not null;index" json:"invoiceId"`
+
+	OrderItemID        string `gorm:"size:64" json:"orderItemId"`
+	ErpItemID          string `gorm:"size:64" json:"erpItemId"`
+	DeliveryNoteItemID string `gorm:"size:64" json:"deliveryNoteItemId"` // erpItemID of the delivery document item
+	StockKeepingUnit   string `gorm:"size:100" json:"stockKeepingUnit"`
+	Name               string `gorm:"size:255" json:"name"`
+
+	Quantity  float64 `json:"quantity"`
+	UnitPrice float64 `json:"unitPrice"`
+	TaxRate   float64 `json:"taxRate"` // percent
+	NetAmount float64 `json:"netAmount"`
+	TaxAmount float64 `json:"taxAmount"`
+}
+
+func (InvoiceLine) TableName() string {
+	return "invoice_lines"
+}
+
+// roundCents rounds an amount to 2 decimal places
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// SetTotals prices every line and sums them into the invoice amounts
+func (inv *Invoice) SetTotals() {
+	inv.NetAmount, inv.TaxAmount = 0, 0
+	for i := range inv.Lines {
+		line := &inv.Lines[i]
+		line.NetAmount = roundCents(line.Quantity * line.UnitPrice)
+		line.TaxAmount = roundCents(line.NetAmount * line.TaxRate / 100)
+		inv.NetAmount += line.NetAmount
+		inv.TaxAmount += line.TaxAmount
+	}
+	inv.NetAmount = roundCents(inv.NetAmount)
+	inv.TaxAmount = roundCents(inv.TaxAmount)
+	inv.TotalAmount = roundCents(inv.NetAmount + inv.TaxAmount)
+}
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"time"
+)
+
+// Job types
+const (
+	TypeCustomerID = "CUSTOMER_ID"
+	TypeVendorID   = "VENDOR_ID"
+	TypeSalesOrder = "SALES_ORDER_ID"
+	TypeInvoice    = "INVOICE"
+)
+
+// Job statuses
+const (
+	StatusPending   = "PENDING"
+	StatusRunning   = "RUNNING"
+	StatusFailed    = "FAILED"
+	StatusCompleted = "COMPLETED"
+)
+
+// Job is a deferred unit of work (ERP ID assignment) persisted so that it
+// survives restarts. EntityID is the primary key of the row the job updates;
+// EntityRef is the client-facing key of that row (farmerId / orderId).
+type Job struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Type        string     `gorm:"size:64;not null;index:idx_jobs_entity" json:"type"`
+	CoopID      string     `gorm:"size:64;not null;index" json:"coopId"`
+	EntityID    uint       `gorm:"not null;index:idx_jobs_entity" json:"entityId"`
+	EntityRef   string     `gorm:"size:128;index" json:"entityRef"`
+	Status      string     `gorm:"size:16;not null;index" json:"status"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"not null" json:"maxAttempts"`
+	RunAt       time.Time  `gorm:"not null;index" json:"runAt"`
+	LockedAt    *time.Time `gorm:"default:null" json:"lockedAt"`
+	LastError   string     `gorm:"type:text" json:"lastError"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `gorm:"default:null" json:"completedAt"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
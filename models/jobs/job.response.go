@@ -0,0 +1,42 @@
+package jobs
+
+type JobResponse struct {
+	ID          uint   `json:"id"`
+	Type        string `json:"type"`
+	CoopID      string `json:"coopId"`
+	EntityID    uint   `json:"entityId"`
+	EntityRef   string `json:"entityRef"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	MaxAttempts int    `json:"maxAttempts"`
+	RunAt       string `json:"runAt"`
+	LastError   string `json:"lastError"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+	CompletedAt string `json:"completedAt"`
+}
+
+type JobDetailResponse struct {
+	Success bool        `json:"success"`
+	Data    JobResponse `json:"data"`
+}
+
+type ListJobsResponse struct {
+	Data       []JobResponse  `json:"data"`
+	Pagination PaginationInfo `json:"pagination"`
+}
+
+type ErrorJobResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// PaginationInfo matches the required pagination format
+type PaginationInfo struct {
+	Page        int  `json:"page"`
+	Limit       int  `json:"limit"`
+	TotalItems  int  `json:"total_items"`
+	TotalPages  int  `json:"total_pages"`
+	HasPrevious bool `json:"has_previous"`
+	HasNext     bool `json:"has_next"`
+}
@@ -0,0 +1,185 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is one recorded request/response pair. Entries are written as
+// one JSON object per line, which is also the format cmd tools read.
+type Entry struct {
+	ID              uint64            `json:"id"`
+	Time            time.Time         `json:"time"`
+	Method          string            `json:"method"`
+	Path            string            `json:"path"`
+	Query           string            `json:"query,omitempty"`
+	Route           string            `json:"route"`
+	Handler         string            `json:"handler"`
+	CoopID          string            `json:"coopId"`
+	RequestHeaders  map[string]string `json:"requestHeaders"`
+	RequestBody     string            `json:"requestBody"`
+	Status          int               `json:"status"`
+	ResponseHeaders map[string]string `json:"responseHeaders"`
+	ResponseBody    string            `json:"responseBody"`
+	LatencyMs       float64           `json:"latencyMs"`
+	Dropped         bool              `json:"dropped,omitempty"`
+	Truncated       bool              `json:"truncated,omitempty"`
+}
+
+// Journal keeps the most recent entries in memory and appends every entry
+// to a size-rotated JSONL file (path, path.1, path.2, ...).
+type Journal struct {
+	mu sync.Mutex
+
+	ring  []Entry
+	head  int // next slot to write
+	count int
+
+	nextID uint64
+
+	path     string
+	maxBytes int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// New creates a journal. An empty path keeps entries in memory only.
+func New(path string, maxBytes int64, maxFiles, bufferSize int) (*Journal, error) {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+
+	j := &Journal{
+		ring:     make([]Entry, bufferSize),
+		nextID:   1,
+		path:     path,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+	}
+
+	if path != "" {
+		if err := j.openFile(); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+func (j *Journal) openFile() error {
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	j.file = f
+	j.size = info.Size()
+	return nil
+}
+
+// rotate shifts path.N -> path.N+1, dropping files beyond maxFiles
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+
+	if j.maxFiles > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", j.path, j.maxFiles))
+		for n := j.maxFiles - 1; n >= 1; n-- {
+			os.Rename(fmt.Sprintf("%s.%d", j.path, n), fmt.Sprintf("%s.%d", j.path, n+1))
+		}
+		if err := os.Rename(j.path, j.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(j.path); err != nil {
+		return err
+	}
+
+	return j.openFile()
+}
+
+// Record assigns the entry an ID, stores it and returns the ID
+func (j *Journal) Record(e Entry) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e.ID = j.nextID
+	j.nextID++
+
+	j.ring[j.head] = e
+	j.head = (j.head + 1) % len(j.ring)
+	if j.count < len(j.ring) {
+		j.count++
+	}
+
+	if j.file == nil {
+		return e.ID, nil
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return e.ID, err
+	}
+	line = append(line, '\n')
+
+	if j.maxBytes > 0 && j.size > 0 && j.size+int64(len(line)) > j.maxBytes {
+		if err := j.rotate(); err != nil {
+			return e.ID, err
+		}
+	}
+
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+	return e.ID, err
+}
+
+// Search returns buffered entries accepted by match, newest first
+func (j *Journal) Search(match func(*Entry) bool, limit int) []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	out := make([]Entry, 0)
+	for i := 1; i <= j.count; i++ {
+		e := &j.ring[(j.head-i+len(j.ring))%len(j.ring)]
+		if match != nil && !match(e) {
+			continue
+		}
+		out = append(out, *e)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
+
+// Get returns a buffered entry by ID
+func (j *Journal) Get(id uint64) (Entry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i := 0; i < j.count; i++ {
+		if j.ring[i].ID == id {
+			return j.ring[i], true
+		}
+	}
+	return Entry{}, false
+}
+
+// Clear empties the in-memory buffer; the file is left untouched
+func (j *Journal) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i := range j.ring {
+		j.ring[i] = Entry{}
+	}
+	j.head = 0
+	j.count = 0
+}
@@ -0,0 +1,43 @@
+package photos
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Photo is one delivery photo of a waybill. Uploaded photos live in the
+// blob store under their SHA-256; photos given as plain URLs (the legacy
+// url1/url2 proof fields) only carry the URL.
+type Photo struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	FileName    string    `json:"fileName,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	UploadedAt  time.Time `json:"uploadedAt"`
+}
+
+// Stored reports whether the photo's bytes are in the blob store
+func (p Photo) Stored() bool {
+	return p.SHA256 != ""
+}
+
+// Linked turns plain photo URLs (the legacy url1/url2 proof fields) into
+// photos, skipping empty ones
+func Linked(uploadedAt time.Time, urls ...string) []Photo {
+	var linked []Photo
+	for _, url := range urls {
+		if url = strings.TrimSpace(url); url != "" {
+			linked = append(linked, Photo{ID: uuid.NewString(), URL: url, UploadedAt: uploadedAt})
+		}
+	}
+	return linked
+}
+
+// PhotoListResponse is the JSON photo list of a waybill
+type PhotoListResponse struct {
+	Photos []Photo `json:"photos"`
+}
@@ -0,0 +1,140 @@
+package photos
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrEmpty       = errors.New("photo is empty")
+	ErrTooLarge    = errors.New("photo is too large")
+	ErrContentType = errors.New("photo content type is not allowed")
+	ErrNotFound    = errors.New("photo not found")
+	ErrCorrupt     = errors.New("stored photo does not match its checksum")
+)
+
+var checksumPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Blob describes bytes written to the store
+type Blob struct {
+	SHA256      string
+	ContentType string
+	Size        int64
+}
+
+// Store is a content-addressed blob store on the local filesystem. Blobs
+// are kept as dir/<first 2 hex digits>/<sha256>, so identical photos are
+// stored once.
+type Store struct {
+	dir          string
+	maxBytes     int64
+	allowedTypes map[string]bool
+}
+
+// NewStore creates the store directory if needed
+func NewStore(dir string, maxBytes int64, allowedTypes []string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir, maxBytes: maxBytes, allowedTypes: map[string]bool{}}
+	for _, t := range allowedTypes {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			s.allowedTypes[t] = true
+		}
+	}
+	return s, nil
+}
+
+// MaxBytes is the largest photo the store accepts
+func (s *Store) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Check validates a photo and describes the blob it would be stored as,
+// without writing it. The content type is sniffed from the bytes rather
+// than trusted from the client.
+func (s *Store) Check(data []byte) (Blob, error) {
+	if len(data) == 0 {
+		return Blob{}, ErrEmpty
+	}
+	if int64(len(data)) > s.maxBytes {
+		return Blob{}, fmt.Errorf("%w (max %d bytes)", ErrTooLarge, s.maxBytes)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !s.allowedTypes[contentType] {
+		return Blob{}, fmt.Errorf("%w (%s)", ErrContentType, contentType)
+	}
+
+	sum := sha256.Sum256(data)
+	return Blob{SHA256: hex.EncodeToString(sum[:]), ContentType: contentType, Size: int64(len(data))}, nil
+}
+
+// Put validates and stores a photo
+func (s *Store) Put(data []byte) (Blob, error) {
+	blob, err := s.Check(data)
+	if err != nil {
+		return Blob{}, err
+	}
+
+	path := s.path(blob.SHA256)
+	if _, err := os.Stat(path); err == nil {
+		return blob, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Blob{}, err
+	}
+
+	// Write then rename, so a reader never sees a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), blob.SHA256+".*.tmp")
+	if err != nil {
+		return Blob{}, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return Blob{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return Blob{}, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return Blob{}, err
+	}
+	return blob, nil
+}
+
+// Get reads a blob and verifies it against its checksum
+func (s *Store) Get(checksum string) ([]byte, error) {
+	if !checksumPattern.MatchString(checksum) {
+		return nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(s.path(checksum))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != checksum {
+		return nil, ErrCorrupt
+	}
+	return data, nil
+}
+
+func (s *Store) path(checksum string) string {
+	return filepath.Join(s.dir, checksum[:2], checksum)
+}
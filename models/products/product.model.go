@@ -1,13 +1,28 @@
 package products
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Product is a catalog item that sales order lines reference by product_group.
+// Inactive products, and products not offered to the order's coop, are rejected.
 type Product struct {
-	ID              uint    `json:"id" gorm:"primaryKey"`
-	ProductCode     string  `json:"product_code" gorm:"size:64;uniqueIndex;not null"`
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ProductCode   string    `json:"product_code" gorm:"size:64;uniqueIndex;not null"`
+	Name          string    `json:"name" gorm:"size:255"`
+	UnitOfMeasure string    `json:"unit_of_measure" gorm:"size:32"`
+	UnitPrice     float64   `json:"unit_price"`                  // price list, per unit of measure
+	TaxCategory   string    `json:"tax_category" gorm:"size:64"` // matched by tax rules like the product code
+	Active        *bool     `json:"active" gorm:"not null;default:true"`
+	Coops         []string  `json:"coops" gorm:"type:json;serializer:json"` // empty: every coop
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 func (s *Product) BeforeCreate(tx *gorm.DB) error {
@@ -19,4 +34,79 @@ func (s *Product) BeforeCreate(tx *gorm.DB) error {
 
 func (Product) TableName() string {
 	return "products"
-}
\ No newline at end of file
+}
+
+var productCodePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Normalize trims the fields and drops blank coops
+func (s *Product) Normalize() {
+	s.ProductCode = strings.TrimSpace(s.ProductCode)
+	s.Name = strings.TrimSpace(s.Name)
+	s.UnitOfMeasure = strings.ToUpper(strings.TrimSpace(s.UnitOfMeasure))
+	s.TaxCategory = strings.TrimSpace(s.TaxCategory)
+
+	coops := make([]string, 0, len(s.Coops))
+	for _, coop := range s.Coops {
+		if coop = strings.TrimSpace(coop); coop != "" {
+			coops = append(coops, coop)
+		}
+	}
+	s.Coops = coops
+}
+
+// Validate checks a product before it is stored
+func (s *Product) Validate() error {
+	if s.ProductCode != "" && !productCodePattern.MatchString(s.ProductCode) {
+		return fmt.Errorf("product_code must only contain letters, digits and . _ -")
+	}
+	if s.UnitPrice < 0 {
+		return fmt.Errorf("unit_price must not be negative")
+	}
+	return nil
+}
+
+// IsActive reports whether the product can be ordered; a nil Active is
+// stored as the column default (true)
+func (s *Product) IsActive() bool {
+	return s.Active == nil || *s.Active
+}
+
+// AvailableIn reports whether coopId can order the product
+func (s *Product) AvailableIn(coopId string) bool {
+	if len(s.Coops) == 0 {
+		return true
+	}
+	for _, coop := range s.Coops {
+		if coop == coopId {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateProductSchema represents the admin create request body
+type CreateProductSchema struct {
+	ProductCode   string   `json:"product_code" example:"IIT-201"` // generated when empty
+	Name          string   `json:"name" example:"Urea 46% 50kg"`
+	UnitOfMeasure string   `json:"unit_of_measure" example:"BAG"`
+	UnitPrice     float64  `json:"unit_price" example:"266.5"`
+	TaxCategory   string   `json:"tax_category" example:"FERTILIZER"`
+	Active        *bool    `json:"active" example:"true"` // default true
+	Coops         []string `json:"coops" example:"COOP019"`
+}
+
+// UpdateProductSchema represents the admin update request body; omitted
+// fields are kept and the product code cannot change
+type UpdateProductSchema struct {
+	Name          *string   `json:"name" example:"Urea 46% 50kg"`
+	UnitOfMeasure *string   `json:"unit_of_measure" example:"BAG"`
+	UnitPrice     *float64  `json:"unit_price" example:"266.5"`
+	TaxCategory   *string   `json:"tax_category" example:"FERTILIZER"`
+	Active        *bool     `json:"active" example:"false"`
+	Coops         *[]string `json:"coops" example:"COOP019"`
+}
+
+// SetPriceSchema represents the PUT /admin/pricelist/:productCode body
+type SetPriceSchema struct {
+	UnitPrice float64 `json:"unit_price" example:"120.5"`
+}
@@ -0,0 +1,81 @@
+package products
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TaxRule sets the tax rate of order lines. ProductGroup is a product code
+// or a tax category; an empty CoopID or ProductGroup matches any. The most
+// specific rule wins (see Match).
+type TaxRule struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CoopID       string    `gorm:"size:64;not null;default:'';uniqueIndex:idx_tax_rule_scope" json:"coopId" example:"COOP019"`
+	ProductGroup string    `gorm:"size:64;not null;default:'';uniqueIndex:idx_tax_rule_scope" json:"productGroup" example:"IIT-101"`
+	Rate         float64   `gorm:"not null" json:"rate" example:"12"` // percent
+	CreatedDate  time.Time `gorm:"autoCreateTime" json:"createdDate"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func (TaxRule) TableName() string {
+	return "tax_rules"
+}
+
+// Normalize trims the scope fields
+func (r *TaxRule) Normalize() {
+	r.CoopID = strings.TrimSpace(r.CoopID)
+	r.ProductGroup = strings.TrimSpace(r.ProductGroup)
+}
+
+// Validate checks a rule before it is stored
+func (r *TaxRule) Validate() error {
+	if r.Rate < 0 || r.Rate > 100 {
+		return fmt.Errorf("rate must be a percentage between 0 and 100")
+	}
+	return nil
+}
+
+// Rule specificities, highest first. A coop's own taxRate ranks between
+// product-group-wide rules and the catch-all rule.
+const (
+	MatchCoopAndGroup = iota + 1
+	MatchCoop
+	MatchGroup
+	MatchAny
+)
+
+// Match reports how specifically r applies to a line of product in coopId,
+// or 0 when it doesn't
+func (r *TaxRule) Match(coopId string, product *Product) int {
+	if r.CoopID != "" && r.CoopID != coopId {
+		return 0
+	}
+	if r.ProductGroup != "" && r.ProductGroup != product.ProductCode &&
+		(product.TaxCategory == "" || r.ProductGroup != product.TaxCategory) {
+		return 0
+	}
+
+	switch {
+	case r.CoopID != "" && r.ProductGroup != "":
+		return MatchCoopAndGroup
+	case r.CoopID != "":
+		return MatchCoop
+	case r.ProductGroup != "":
+		return MatchGroup
+	default:
+		return MatchAny
+	}
+}
+
+// CreateTaxRuleSchema represents the admin create request body
+type CreateTaxRuleSchema struct {
+	CoopID       string  `json:"coopId" example:"COOP019"`
+	ProductGroup string  `json:"productGroup" example:"IIT-101"`
+	Rate         float64 `json:"rate" example:"12"`
+}
+
+// UpdateTaxRuleSchema represents the admin update request body; only the rate can change
+type UpdateTaxRuleSchema struct {
+	Rate float64 `json:"rate" example:"12"`
+}
@@ -9,6 +9,8 @@ import (
 	// "github.com/go-playground/validator/v10"
 	//"github.com/google/uuid"
 
+	"github.com/shyamsundaar/karino-mock-server/clock"
+	"github.com/shyamsundaar/karino-mock-server/models/sequences"
 	"gorm.io/gorm"
 )
 
@@ -20,11 +22,11 @@ import (
 
 type SalesOrder struct {
 	ID     uint   `gorm:"primaryKey;autoIncrement"`
-	TempID string `gorm:"column:temp_id;not null" json:"tempId"`
+	TempID string `gorm:"column:temp_id;size:64;not null;uniqueIndex" json:"tempId"`
 	CoopID string `gorm:"column:coop_id;not null" json:"coopId"`
 
-	ErpSalesOrderId   string `gorm:"column:erp_sales_order_id;size:64" json:"erp_sales_order_id"`
-	ErpSalesOrderCode string `gorm:"column:erp_sales_order_code;size:64" json:"erp_sales_order_code"`
+	ErpSalesOrderId   string `gorm:"column:erp_sales_order_id;size:64;uniqueIndex;default:null" json:"erp_sales_order_id"`
+	ErpSalesOrderCode string `gorm:"column:erp_sales_order_code;size:64;uniqueIndex;default:null" json:"erp_sales_order_code"`
 
 	OrderID     string `gorm:"column:order_id;size:64;uniqueIndex" json:"order_id"`
 	OrderNumber string `gorm:"column:order_number;size:64" json:"order_number"`
@@ -71,6 +73,11 @@ type SalesOrder struct {
 	OrderValue  float64 `gorm:"default:null"`
 	TaxAmount   float64 `gorm:"default:null"`
 	TotalAmount float64 `gorm:"default:null"`
+	Currency    string  `gorm:"size:3" json:"currency"`
+
+	Status             string     `gorm:"column:status;size:32;not null;default:OPEN" json:"status"`
+	CancelledAt        *time.Time `gorm:"default:null" json:"cancelled_at"`
+	CancellationReason string     `gorm:"size:255" json:"cancellation_reason"`
 
 	NoofOrderItems int              `gorm:"column:noof_order_items" json:"noofOrderItems"`
 	OrderItems     []SalesOrderItem `gorm:"foreignKey:OrderID;references:OrderID" json:"order_items"`
@@ -80,52 +87,100 @@ func (SalesOrder) TableName() string {
 	return "sales_orders"
 }
 
-//
-// =======================
-// GORM HOOK
-// =======================
-//
+// Supported values for PRICING_MODE
+const (
+	PricingPriceList = "pricelist"
+	PricingRandom    = "random"
+)
 
-// BeforeCreate Hook to handle any logic before saving to DB
-func (d *SalesOrder) BeforeCreate(tx *gorm.DB) (err error) {
-	now := time.Now()
+// roundCents rounds an amount to 2 decimal places
+func roundCents(value float64) float64 {
+	// Formula: round(value * 100) / 100
+	return math.Round(value*100) / 100
+}
 
-	// Fetch last TempID
-	var lastTempID string
+// setTotals sets the order value and tax rounded to cents, and the total
+func (d *SalesOrder) setTotals(value, tax float64) {
+	d.OrderValue = roundCents(value)
+	d.TaxAmount = roundCents(tax)
+	d.TotalAmount = roundCents(d.OrderValue + d.TaxAmount)
+}
 
-	r := rand.New(rand.NewSource(now.UnixNano()))
+// PriceItems sets the totals from items whose UnitPrice and TaxRate have
+// been resolved: the value is the sum of quantity × unit price per line, the
+// tax the sum of each line's value at its rate.
+func (d *SalesOrder) PriceItems(items []SalesOrderItem) {
+	var value, tax float64
+	for _, item := range items {
+		line := roundCents(item.Quantity * item.UnitPrice)
+		value += line
+		tax += line * item.TaxRate / 100
+	}
+	d.setTotals(value, tax)
+}
 
-	// 1. Generate Random Base Value (e.g., between 5000 and 20000)
-	rawOrderValue := 5000.0 + r.Float64()*(20000.0-5000.0)
+// randomTaxRate is the flat tax of PRICING_MODE=random
+const randomTaxRate = 0.05
 
-	// 2. Calculate and Round to 2 decimal places
-	// Formula: round(value * 100) / 100
-	d.OrderValue = math.Round(rawOrderValue*100) / 100
+// RandomizeTotals is the PRICING_MODE=random opt-in: a value between 5000 and
+// 20000 regardless of the items, with 5% tax
+func (d *SalesOrder) RandomizeTotals() {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
-	// Calculate Tax (e.g., 5%) and round
-	d.TaxAmount = math.Round((d.OrderValue*0.05)*100) / 100
+	value := roundCents(5000.0 + r.Float64()*(20000.0-5000.0))
+	d.setTotals(value, value*randomTaxRate)
+}
 
-	// Final Total
-	d.TotalAmount = d.OrderValue + d.TaxAmount
+// Reprice is the PRICING_MODE=random counterpart of PriceItems for an
+// amendment from oldItems to newItems. Items sent with a unit_price are
+// priced at it; the others at the order's current average price per unit,
+// so amending the quantities of a randomly priced order scales its value.
+func (d *SalesOrder) Reprice(oldItems, newItems []SalesOrderItem) {
+	var oldQuantity float64
+	for _, item := range oldItems {
+		oldQuantity += item.Quantity
+	}
+	rate := 0.0
+	if oldQuantity > 0 {
+		rate = d.OrderValue / oldQuantity
+	}
 
-	err = tx.
-		Model(&SalesOrder{}).
-		Select("temp_id").
-		Where("temp_id IS NOT NULL AND temp_id != ''").
-		Order("id DESC").
-		Limit(1).
-		Scan(&lastTempID).Error
+	var value float64
+	for _, item := range newItems {
+		price := item.UnitPrice
+		if price <= 0 {
+			price = rate
+		}
+		value += price * item.Quantity
+	}
 
-	// Default start value
-	next := 1000
+	value = roundCents(value)
+	d.setTotals(value, value*randomTaxRate)
+}
 
-	if err == nil && lastTempID != "" {
-		if n, convErr := strconv.Atoi(lastTempID); convErr == nil {
-			next = n + 1
-		}
+//
+// =======================
+// GORM HOOK
+// =======================
+//
+
+// BeforeCreate Hook to handle any logic before saving to DB
+func (d *SalesOrder) BeforeCreate(tx *gorm.DB) (err error) {
+	now := clock.Now()
+
+	// The column defaults to OPEN, which marks orders stored before the
+	// lifecycle existed; new orders start out RECEIVED
+	if d.Status == "" {
+		d.Status = StatusReceived
 	}
 
-	d.TempID = strconv.Itoa(next)
+	// Allocate TempID from the shared counter (starts at 1000)
+	next, err := sequences.Next(tx, sequences.SalesOrderTempID, 1000)
+	if err != nil {
+		return err
+	}
+
+	d.TempID = strconv.FormatInt(next, 10)
 	d.CreatedAt = &now
 	d.UpdatedAt = &now
 
@@ -154,10 +209,12 @@ type SalesOrderItem struct {
 	InputItemName        string `gorm:"column:input_item_name;size:128" json:"input_item_name"`
 	InputItemNameCaption string `gorm:"column:input_item_name_caption;size:128" json:"input_item_name_caption"`
 
-	Quantity        float64 `gorm:"column:quantity" json:"quantity"`
-	QuantityUnitKey string  `gorm:"column:quantity_unit_key;size:32" json:"quantity_unit_key"`
+	Quantity          float64 `gorm:"column:quantity" json:"quantity"`
+	DeliveredQuantity float64 `gorm:"column:delivered_quantity;not null;default:0" json:"delivered_quantity"`
+	QuantityUnitKey   string  `gorm:"column:quantity_unit_key;size:32" json:"quantity_unit_key"`
 
 	UnitPrice    float64 `gorm:"column:unit_price" json:"unit_price"`
+	TaxRate      float64 `gorm:"column:tax_rate" json:"tax_rate"` // percent, resolved when priced
 	Price        string  `gorm:"column:price;size:32" json:"price"`
 	PriceUnitKey string  `gorm:"column:price_unit_key;size:32" json:"price_unit_key"`
 
@@ -168,6 +225,28 @@ func (SalesOrderItem) TableName() string {
 	return "sales_order_items"
 }
 
+// QuantityEpsilon absorbs float rounding when comparing quantities
+const QuantityEpsilon = 1e-9
+
+// OpenQuantity is the quantity not yet on a delivery document
+func (item *SalesOrderItem) OpenQuantity() float64 {
+	open := item.Quantity - item.DeliveredQuantity
+	if open < QuantityEpsilon {
+		return 0
+	}
+	return open
+}
+
+// FullyDelivered reports whether every item is on delivery documents
+func FullyDelivered(items []SalesOrderItem) bool {
+	for i := range items {
+		if items[i].OpenQuantity() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
 //
 // =======================
 // VALIDATION + ERRORS
@@ -230,3 +309,92 @@ type CreateSalesOrderSchema struct {
 
 	OrderItems []SalesOrderItem `json:"order_items"`
 }
+
+// CancelSalesOrderSchema is the optional body of POST /salesorders/:orderId/cancel
+type CancelSalesOrderSchema struct {
+	Reason string `json:"reason" example:"Farmer withdrew the order"`
+}
+
+// Apply copies the order-level fields of the payload onto order
+func (p *CreateSalesOrderSchema) Apply(order *SalesOrder) {
+	order.OrderID = p.OrderID
+	order.OrderNumber = p.OrderNumber
+	order.ContractID = p.ContractID
+
+	order.FarmerID = p.FarmerID
+	order.FarmerName = p.FarmerName
+
+	order.ClubID = p.ClubID
+	order.ClubName = p.ClubName
+
+	order.FarmerResourceCategory = p.FarmerResourceCategory
+	order.ContractCrop = p.ContractCrop
+	order.ContractCropVareity = p.ContractCropVareity
+	order.ContractArea = p.ContractArea
+
+	order.SponsorID = p.SponsorID
+	order.SponsorName = p.SponsorName
+
+	order.BuyerID = p.BuyerID
+	order.BuyerName = p.BuyerName
+
+	order.PackageSetCaptionPT = p.PackageSetCaptionPT
+
+	order.RegionID = p.RegionID
+	order.RegionPartID = p.RegionPartID
+	order.SettlementID = p.SettlementID
+	order.SettlementPartID = p.SettlementPartID
+
+	order.CustomZone1ID = p.CustomZone1ID
+	order.CustomZone2ID = p.CustomZone2ID
+
+	order.PickupDate = p.PickupDate
+	order.CreatedBy = p.CreatedBy
+
+	order.NoofOrderItems = len(p.OrderItems)
+}
+
+// NewSalesOrderSchema is the inverse of Apply: the payload that would
+// (re)create order with items. PATCH merges its changes onto it.
+func NewSalesOrderSchema(order *SalesOrder, items []SalesOrderItem) CreateSalesOrderSchema {
+	return CreateSalesOrderSchema{
+		OrderID:     order.OrderID,
+		OrderNumber: order.OrderNumber,
+		ContractID:  order.ContractID,
+
+		FarmerID:   order.FarmerID,
+		FarmerName: order.FarmerName,
+
+		ClubID:   order.ClubID,
+		ClubName: order.ClubName,
+
+		FarmerResourceCategory: order.FarmerResourceCategory,
+		ContractCrop:           order.ContractCrop,
+		ContractCropVareity:    order.ContractCropVareity,
+		ContractArea:           order.ContractArea,
+
+		SponsorID:   order.SponsorID,
+		SponsorName: order.SponsorName,
+
+		BuyerID:   order.BuyerID,
+		BuyerName: order.BuyerName,
+
+		PackageSetCaptionPT: order.PackageSetCaptionPT,
+
+		RegionID:         order.RegionID,
+		RegionPartID:     order.RegionPartID,
+		SettlementID:     order.SettlementID,
+		SettlementPartID: order.SettlementPartID,
+
+		CustomZone1ID: order.CustomZone1ID,
+		CustomZone2ID: order.CustomZone2ID,
+
+		PickupDate: order.PickupDate,
+		CreatedBy:  order.CreatedBy,
+
+		RaithuCreatedAt: order.RaithuCreatedAt,
+		RaithuUpdatedAt: order.RaithuUpdatedAt,
+
+		OrderItems: items,
+	}
+}
@@ -11,4 +11,6 @@ type SalesOrderAmountResponse struct {
 	OrderValue          float64 `json:"orderValue"`
 	TaxAmount           float64 `json:"taxAmount"`
 	TotalAmount         float64 `json:"totalAmount"`
+	Currency            string  `json:"currency"`
+	Status              string  `json:"status"`
 }
@@ -5,6 +5,7 @@ type SalesOrderListResponse struct {
 	ErpSalesOrderId     string `json:"erpSalesOrderId"`
 	ErpSalesOrderCode   string `json:"erpSalesOrderCode"`
 	SpicSalesOrderId    string `json:"spicSalesOrderId"`
+	Status              string `json:"status"`
 	CreatedAt           string `json:"created_at"`
 	UpdatedAt           string `json:"updated_at"`
 }
@@ -0,0 +1,95 @@
+package sales
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sales order statuses. An order is RECEIVED until its ERP ID is assigned,
+// follows its delivered quantities through PARTIALLY_DELIVERED and
+// DELIVERED, and is INVOICED once every delivery document is invoiced.
+const (
+	StatusReceived           = "RECEIVED"
+	StatusConfirmed          = "CONFIRMED"
+	StatusPartiallyDelivered = "PARTIALLY_DELIVERED"
+	StatusDelivered          = "DELIVERED"
+	StatusInvoiced           = "INVOICED"
+	StatusCancelled          = "CANCELLED"
+
+	// StatusOpen is the column default, so it marks orders stored before
+	// the lifecycle existed until BackfillSalesOrderStatuses moves them on
+	StatusOpen = "OPEN"
+)
+
+// Statuses lists the lifecycle in order
+var Statuses = []string{
+	StatusReceived, StatusConfirmed, StatusPartiallyDelivered, StatusDelivered, StatusInvoiced, StatusCancelled,
+}
+
+// transitions are the allowed moves. Voiding delivery documents moves an
+// order back from (partially) delivered.
+var transitions = map[string][]string{
+	StatusReceived:           {StatusConfirmed, StatusCancelled},
+	StatusConfirmed:          {StatusPartiallyDelivered, StatusDelivered, StatusCancelled},
+	StatusPartiallyDelivered: {StatusConfirmed, StatusDelivered},
+	StatusDelivered:          {StatusConfirmed, StatusPartiallyDelivered, StatusInvoiced},
+}
+
+var ErrInvalidTransition = errors.New("invalid sales order status transition")
+
+// ValidateTransition checks that an order may move from one status to another
+func ValidateTransition(from, to string) error {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
+}
+
+// ValidStatus reports whether status is part of the lifecycle
+func ValidStatus(status string) bool {
+	for _, s := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the status a confirmed order's delivered quantities
+// put it in
+func DeliveryStatus(items []SalesOrderItem) string {
+	if len(items) > 0 && FullyDelivered(items) {
+		return StatusDelivered
+	}
+	for i := range items {
+		if items[i].DeliveredQuantity > QuantityEpsilon {
+			return StatusPartiallyDelivered
+		}
+	}
+	return StatusConfirmed
+}
+
+// SalesOrderStatusChange is one entry of an order's status history. The
+// first entry of an order has an empty FromStatus.
+type SalesOrderStatusChange struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	CoopID     string    `gorm:"size:64;not null" json:"-"`
+	OrderID    string    `gorm:"size:64;not null;index" json:"-"`
+	FromStatus string    `gorm:"size:32" json:"fromStatus"`
+	ToStatus   string    `gorm:"size:32;not null" json:"toStatus"`
+	Reason     string    `gorm:"size:255" json:"reason"`
+	CreatedAt  time.Time `json:"changedAt"`
+}
+
+func (SalesOrderStatusChange) TableName() string {
+	return "sales_order_status_history"
+}
+
+type SalesOrderStatusHistoryResponse struct {
+	SpicSalesOrderId string                   `json:"spicSalesOrderId"`
+	Status           string                   `json:"status"`
+	History          []SalesOrderStatusChange `json:"history"`
+}
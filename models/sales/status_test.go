@@ -0,0 +1,72 @@
+package sales
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateTransition(t *testing.T) {
+	allowed := map[string][]string{
+		StatusReceived:           {StatusConfirmed, StatusCancelled},
+		StatusConfirmed:          {StatusPartiallyDelivered, StatusDelivered, StatusCancelled},
+		StatusPartiallyDelivered: {StatusConfirmed, StatusDelivered},
+		StatusDelivered:          {StatusConfirmed, StatusPartiallyDelivered, StatusInvoiced},
+	}
+
+	from := append([]string{StatusOpen}, Statuses...)
+	for _, f := range from {
+		for _, to := range Statuses {
+			want := false
+			for _, a := range allowed[f] {
+				if a == to {
+					want = true
+				}
+			}
+
+			err := ValidateTransition(f, to)
+			if want && err != nil {
+				t.Errorf("%s -> %s: unexpected error %v", f, to, err)
+			}
+			if !want && !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("%s -> %s: error = %v, want ErrInvalidTransition", f, to, err)
+			}
+		}
+	}
+}
+
+func TestValidStatus(t *testing.T) {
+	tests := map[string]bool{
+		StatusReceived:  true,
+		StatusInvoiced:  true,
+		StatusCancelled: true,
+		StatusOpen:      false,
+		"":              false,
+		"confirmed":     false,
+	}
+	for status, want := range tests {
+		if got := ValidStatus(status); got != want {
+			t.Errorf("ValidStatus(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestDeliveryStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []SalesOrderItem
+		want  string
+	}{
+		{"no items", nil, StatusConfirmed},
+		{"nothing delivered", []SalesOrderItem{{Quantity: 5}, {Quantity: 2}}, StatusConfirmed},
+		{"below epsilon", []SalesOrderItem{{Quantity: 5, DeliveredQuantity: QuantityEpsilon / 2}}, StatusConfirmed},
+		{"one item partly", []SalesOrderItem{{Quantity: 5, DeliveredQuantity: 1}, {Quantity: 2}}, StatusPartiallyDelivered},
+		{"one item fully", []SalesOrderItem{{Quantity: 5, DeliveredQuantity: 5}, {Quantity: 2}}, StatusPartiallyDelivered},
+		{"all items fully", []SalesOrderItem{{Quantity: 5, DeliveredQuantity: 5}, {Quantity: 2, DeliveredQuantity: 2}}, StatusDelivered},
+		{"within epsilon", []SalesOrderItem{{Quantity: 0.3, DeliveredQuantity: 0.1 + 0.2}}, StatusDelivered},
+	}
+	for _, tt := range tests {
+		if got := DeliveryStatus(tt.items); got != tt.want {
+			t.Errorf("%s: DeliveryStatus = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package scenarios
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/shyamsundaar/karino-mock-server/models/stubs"
+)
+
+// DefaultState is where a scenario starts unless it says otherwise
+const DefaultState = "Started"
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Response overrides what the wrapped handler would have returned
+type Response struct {
+	Status  int               `json:"status" example:"504"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"` // stub template, see models/stubs
+	DelayMs int               `json:"delayMs" example:"30000"`
+	Drop    bool              `json:"drop"`
+}
+
+// Step fires when the scenario is in State and a request matches. It moves
+// the scenario to NextState and, if Response is set, answers in place of
+// the real handler; otherwise the request passes through.
+type Step struct {
+	State          string                `json:"state" example:"Started"`
+	Method         string                `json:"method" example:"POST"`
+	Route          string                `json:"route" example:"/spic_to_erp/customers/:coopId/salesorders"`
+	Headers        map[string]string     `json:"headers"`
+	BodyConditions []stubs.BodyCondition `json:"bodyConditions"`
+	Response       *Response             `json:"response"`
+	NextState      string                `json:"nextState" example:"Retried"`
+}
+
+// Scenario is a named state machine scripted over incoming requests
+type Scenario struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name         string    `gorm:"size:128;not null;uniqueIndex" json:"name" example:"order-timeout"`
+	Description  string    `gorm:"type:text" json:"description"`
+	InitialState string    `gorm:"size:128;not null" json:"initialState"`
+	State        string    `gorm:"size:128;not null" json:"state"`
+	Steps        []Step    `gorm:"type:json;serializer:json" json:"steps"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func (Scenario) TableName() string {
+	return "scenarios"
+}
+
+// Validate fills defaults and checks steps and templates
+func (s *Scenario) Validate() error {
+	if !namePattern.MatchString(s.Name) {
+		return fmt.Errorf("name must only contain letters, digits, '.', '_' or '-'")
+	}
+	if s.InitialState == "" {
+		s.InitialState = DefaultState
+	}
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("scenario needs at least one step")
+	}
+
+	for i := range s.Steps {
+		step := &s.Steps[i]
+		if step.State == "" {
+			step.State = s.InitialState
+		}
+		if step.Route == "" {
+			return fmt.Errorf("steps[%d]: route is required", i)
+		}
+
+		// Reuse the stub validator for route, conditions and template
+		matcher := step.matcher()
+		if step.Response != nil {
+			matcher.ResponseStatus = step.Response.Status
+			matcher.ResponseBody = step.Response.Body
+			if step.Response.Status == 0 && !step.Response.Drop {
+				step.Response.Status = 200
+			}
+			if step.Response.DelayMs < 0 {
+				return fmt.Errorf("steps[%d]: delayMs must not be negative", i)
+			}
+		}
+		if err := matcher.Validate(); err != nil {
+			return fmt.Errorf("steps[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (step *Step) matcher() stubs.Stub {
+	return stubs.Stub{
+		Method:         step.Method,
+		PathTemplate:   step.Route,
+		Headers:        step.Headers,
+		BodyConditions: step.BodyConditions,
+	}
+}
+
+// Match returns the first step for the current state that matches the request
+func (s *Scenario) Match(method, path string, req *stubs.Request) (*Step, bool) {
+	for i := range s.Steps {
+		step := &s.Steps[i]
+		if step.State != s.State {
+			continue
+		}
+		m := step.matcher()
+		if m.Match(method, path, req) {
+			return step, true
+		}
+	}
+	return nil, false
+}
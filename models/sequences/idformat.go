@@ -0,0 +1,170 @@
+package sequences
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IDFormat is a parsed identifier template. Supported tokens:
+//
+//	{YYYY}  four digit year          {YY}    two digit year
+//	{SEQ}   next counter value       {SEQ:5} counter zero-padded to 5 digits
+//	{UUID}  random UUID              {COOP}  cooperative ID
+//
+// Adding "yearly" to the counter ({SEQ:5:yearly} or {SEQ:yearly}) restarts it at 1 every year;
+// the template then needs a year token so IDs do not repeat.
+// Examples: "C26{SEQ:5}" → C2600001, "ECL {YYYY}/{SEQ}" → ECL 2026/1, "{UUID}".
+type IDFormat struct {
+	Template    string
+	parts       []formatPart
+	usesSeq     bool
+	usesUUID    bool
+	usesCoop    bool
+	usesYear    bool
+	resetYearly bool
+}
+
+type formatPart struct {
+	literal string
+	token   string
+	padding int
+}
+
+var formatTokenPattern = regexp.MustCompile(`\{([A-Z]+)((?::[^}:]*)*)\}`)
+
+// ParseIDFormat validates a template; it must contain {SEQ} or {UUID} so generated IDs differ
+func ParseIDFormat(template string) (*IDFormat, error) {
+	f := &IDFormat{Template: template}
+
+	last := 0
+	for _, m := range formatTokenPattern.FindAllStringSubmatchIndex(template, -1) {
+		if m[0] > last {
+			f.parts = append(f.parts, formatPart{literal: template[last:m[0]]})
+		}
+		last = m[1]
+
+		token := template[m[2]:m[3]]
+		args := strings.Split(strings.TrimPrefix(template[m[4]:m[5]], ":"), ":")
+		part := formatPart{token: token}
+
+		switch token {
+		case "YYYY", "YY":
+			f.usesYear = true
+		case "COOP":
+			f.usesCoop = true
+		case "UUID":
+			f.usesUUID = true
+		case "SEQ":
+			f.usesSeq = true
+			for _, arg := range args {
+				switch {
+				case arg == "":
+				case arg == "yearly":
+					f.resetYearly = true
+				default:
+					n, err := strconv.Atoi(arg)
+					if err != nil || n < 0 || n > 20 {
+						return nil, fmt.Errorf("invalid {SEQ} option %q in format %q", arg, template)
+					}
+					part.padding = n
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown token {%s} in format %q", token, template)
+		}
+
+		f.parts = append(f.parts, part)
+	}
+	if last < len(template) {
+		f.parts = append(f.parts, formatPart{literal: template[last:]})
+	}
+
+	if strings.ContainsAny(strings.Join(literals(f.parts), ""), "{}") {
+		return nil, fmt.Errorf("malformed token in format %q", template)
+	}
+	if !f.usesSeq && !f.usesUUID {
+		return nil, fmt.Errorf("format %q needs a {SEQ} or {UUID} token", template)
+	}
+	// A counter that restarts every year repeats its IDs unless they carry the year
+	if f.resetYearly && !f.usesYear && !f.usesUUID {
+		return nil, fmt.Errorf("format %q restarts its counter yearly, so it needs a {YYYY} or {YY} token", template)
+	}
+
+	return f, nil
+}
+
+// ParseCoopIDFormat validates a cooperative's override template. Such a
+// template has its own counter, so unless it has a {UUID} it must contain
+// {COOP} to keep its IDs apart from those of the shared counter.
+func ParseCoopIDFormat(template string) (*IDFormat, error) {
+	f, err := ParseIDFormat(template)
+	if err != nil {
+		return nil, err
+	}
+	if !f.usesUUID && !f.usesCoop {
+		return nil, fmt.Errorf("cooperative format %q needs a {COOP} or {UUID} token", template)
+	}
+	return f, nil
+}
+
+func literals(parts []formatPart) []string {
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		out = append(out, p.literal)
+	}
+	return out
+}
+
+// SequenceName is the counter backing this format: base, narrowed to the
+// cooperative when scope is set and to the year when the counter resets yearly
+func (f *IDFormat) SequenceName(base, scope string, now time.Time) string {
+	name := base
+	if scope != "" {
+		name += ":" + scope
+	}
+	if f.resetYearly {
+		name += ":" + strconv.Itoa(now.Year())
+	}
+	return name
+}
+
+// Render builds the identifier for the given counter value
+func (f *IDFormat) Render(coopId string, now time.Time, seq int64) string {
+	var b strings.Builder
+	for _, p := range f.parts {
+		switch p.token {
+		case "":
+			b.WriteString(p.literal)
+		case "YYYY":
+			b.WriteString(fmt.Sprintf("%04d", now.Year()))
+		case "YY":
+			b.WriteString(fmt.Sprintf("%02d", now.Year()%100))
+		case "COOP":
+			b.WriteString(coopId)
+		case "UUID":
+			b.WriteString(uuid.New().String())
+		case "SEQ":
+			b.WriteString(fmt.Sprintf("%0*d", p.padding, seq))
+		}
+	}
+	return b.String()
+}
+
+// Generate takes the next counter value (when the format has one) and renders the identifier
+func (f *IDFormat) Generate(db *gorm.DB, base, scope, coopId string, now time.Time) (string, error) {
+	var seq int64
+	if f.usesSeq {
+		var err error
+		seq, err = Next(db, f.SequenceName(base, scope, now), 1)
+		if err != nil {
+			return "", err
+		}
+	}
+	return f.Render(coopId, now, seq), nil
+}
@@ -0,0 +1,98 @@
+package sequences
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseIDFormat(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		template string
+		wantErr  bool
+		seq      int64
+		want     string // rendered with coop COOP019 at now
+	}{
+		{template: "C26{SEQ:5}", seq: 1, want: "C2600001"},
+		{template: "ECL {YYYY}/{SEQ}", seq: 12, want: "ECL 2026/12"},
+		{template: "K{COOP}-{YY}-{SEQ:3:yearly}", seq: 7, want: "KCOOP019-26-007"},
+		{template: "{YY}{SEQ:yearly}", seq: 3, want: "263"},
+		{template: "{UUID}-{SEQ:yearly}"},
+		{template: "{SEQ:yearly}", wantErr: true},
+		{template: "K{COOP}-{SEQ:4:yearly}", wantErr: true},
+		{template: "{UUID}"},
+		{template: "C26", wantErr: true},
+		{template: "{YYYY}-{COOP}", wantErr: true},
+		{template: "C{SEQ:x}", wantErr: true},
+		{template: "C{SEQ:21}", wantErr: true},
+		{template: "C{NOPE}{SEQ}", wantErr: true},
+		{template: "C{seq}", wantErr: true},
+		{template: "C{SEQ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			f, err := ParseIDFormat(tt.template)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseIDFormat(%q) = nil error, want one", tt.template)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseIDFormat(%q) = %v", tt.template, err)
+			}
+			if tt.want == "" {
+				return
+			}
+			if got := f.Render("COOP019", now, tt.seq); got != tt.want {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequenceName(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		template, scope, want string
+	}{
+		{"C{SEQ}", "", "customer"},
+		{"C{COOP}{SEQ}", "COOP019", "customer:COOP019"},
+		{"C{YY}{SEQ:yearly}", "", "customer:2026"},
+		{"C{COOP}{YYYY}{SEQ:5:yearly}", "COOP019", "customer:COOP019:2026"},
+	}
+
+	for _, tt := range tests {
+		f, err := ParseIDFormat(tt.template)
+		if err != nil {
+			t.Fatalf("ParseIDFormat(%q) = %v", tt.template, err)
+		}
+		if got := f.SequenceName("customer", tt.scope, now); got != tt.want {
+			t.Errorf("SequenceName(%q, %q) = %q, want %q", tt.template, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestParseCoopIDFormat(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{"K{COOP}-{YYYY}-{SEQ:6:yearly}", false},
+		{"K{COOP}-{SEQ:6:yearly}", true},
+		{"{UUID}", false},
+		{"K{UUID}-{SEQ}", false},
+		{"K{YYYY}-{SEQ:6:yearly}", true},
+		{"C26", true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseCoopIDFormat(tt.template)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCoopIDFormat(%q) error = %v, want error %v", tt.template, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package sequences
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Names of the counters handed out by Next
+const (
+	FarmerTempID         = "farmer_details.temp_id"
+	SalesOrderTempID     = "sales_orders.temp_id"
+	WaybillTempID        = "way_bill.temp_id"
+	CustomerID           = "farmer_details.customer_id"
+	VendorID             = "farmer_details.vendor_id"
+	ErpSalesOrderID      = "sales_orders.erp_sales_order_id"
+	ErpSalesOrderCode    = "sales_orders.erp_sales_order_code"
+	DeliveryDocumentCode = "delivery_documents.delivery_document_code"
+	InvoiceID            = "invoices.erp_invoice_id"
+	InvoiceCode          = "invoices.erp_invoice_code"
+)
+
+// Sequence is a named counter; Value is the last number handed out
+type Sequence struct {
+	Name  string `gorm:"primaryKey;size:128" json:"name"`
+	Value int64  `gorm:"not null" json:"value"`
+}
+
+func (Sequence) TableName() string {
+	return "sequences"
+}
+
+// Next atomically increments the named counter and returns the new value.
+// The counter is created on first use so that its first value is start.
+//
+// The increment runs in its own transaction (a savepoint when db is already
+// one, e.g. inside a BeforeCreate hook): the UPDATE holds the row lock until
+// commit, so concurrent callers never read the same value.
+func Next(db *gorm.DB, name string, start int64) (int64, error) {
+	var value int64
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&Sequence{Name: name, Value: start - 1}).
+			Error; err != nil {
+			return err
+		}
+
+		if err := tx.
+			Model(&Sequence{}).
+			Where("name = ?", name).
+			UpdateColumn("value", gorm.Expr("value + 1")).
+			Error; err != nil {
+			return err
+		}
+
+		return tx.
+			Model(&Sequence{}).
+			Where("name = ?", name).
+			Pluck("value", &value).
+			Error
+	})
+
+	return value, err
+}
+
+// Seed creates the named counter at value if it does not exist yet.
+// Existing counters are left untouched.
+func Seed(db *gorm.DB, name string, value int64) error {
+	return db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Sequence{Name: name, Value: value}).
+		Error
+}
@@ -0,0 +1,328 @@
+package stubs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shyamsundaar/karino-mock-server/clock"
+)
+
+// Body condition operators
+const (
+	OpEquals   = "equals"
+	OpContains = "contains"
+	OpMatches  = "matches"
+	OpExists   = "exists"
+	OpAbsent   = "absent"
+)
+
+// BodyCondition tests one JSON path of the request body, e.g.
+// {"path": "$.order_items[0].product_group", "op": "equals", "value": "IIT-101"}
+type BodyCondition struct {
+	Path  string `json:"path" example:"$.farmerId"`
+	Op    string `json:"op" example:"equals"`
+	Value string `json:"value" example:"F1"`
+}
+
+// Stub is a runtime-registered response for requests no built-in route handles
+type Stub struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name     string `gorm:"size:255" json:"name"`
+	Priority int    `gorm:"not null;default:0" json:"priority"`
+
+	// Match
+	Method         string            `gorm:"size:16" json:"method" example:"GET"`
+	PathTemplate   string            `gorm:"size:512;not null" json:"pathTemplate" example:"/spic_to_erp/customers/:coopId/invoices/:invoiceId"`
+	Headers        map[string]string `gorm:"type:json;serializer:json" json:"headers"`
+	BodyConditions []BodyCondition   `gorm:"type:json;serializer:json" json:"bodyConditions"`
+
+	// Response; ResponseBody is a Go text/template (see README)
+	ResponseStatus  int               `gorm:"not null;default:200" json:"responseStatus" example:"200"`
+	ResponseHeaders map[string]string `gorm:"type:json;serializer:json" json:"responseHeaders"`
+	ResponseBody    string            `gorm:"type:text" json:"responseBody" example:"{\"invoiceId\": \"{{.Path.invoiceId}}\"}"`
+
+	Hits      int       `gorm:"not null;default:0" json:"hits"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (Stub) TableName() string {
+	return "stubs"
+}
+
+// Validate checks the matcher and compiles the response template
+func (s *Stub) Validate() error {
+	if !strings.HasPrefix(s.PathTemplate, "/") {
+		return fmt.Errorf("pathTemplate must start with /")
+	}
+	if s.ResponseStatus == 0 {
+		s.ResponseStatus = 200
+	}
+	if s.ResponseStatus < 100 || s.ResponseStatus > 599 {
+		return fmt.Errorf("responseStatus must be a valid HTTP status code")
+	}
+	for _, c := range s.BodyConditions {
+		switch c.Op {
+		case OpEquals, OpContains, OpExists, OpAbsent:
+		case OpMatches:
+			if _, err := regexp.Compile(c.Value); err != nil {
+				return fmt.Errorf("bodyConditions %s: %w", c.Path, err)
+			}
+		default:
+			return fmt.Errorf("bodyConditions %s: unknown op %q", c.Path, c.Op)
+		}
+	}
+	if err := ValidateTemplate(s.ResponseBody); err != nil {
+		return fmt.Errorf("responseBody: %w", err)
+	}
+	return nil
+}
+
+// Request is what stubs are matched against and what templates see
+type Request struct {
+	Method  string
+	Path    map[string]string // path params from the template
+	URL     string
+	Query   map[string]string
+	Headers map[string]string
+	Body    interface{} // decoded JSON body, nil if not JSON
+	RawBody string
+}
+
+// NewRequest builds a Request; the body is decoded when it is JSON
+func NewRequest(method, url string, query, headers map[string]string, body []byte) *Request {
+	req := &Request{
+		Method:  method,
+		URL:     url,
+		Query:   query,
+		Headers: headers,
+		RawBody: string(body),
+	}
+
+	var doc interface{}
+	if json.Unmarshal(body, &doc) == nil {
+		req.Body = doc
+	}
+	return req
+}
+
+// Match reports whether the stub applies and fills req.Path with the
+// template's parameters on success.
+func (s *Stub) Match(method, path string, req *Request) bool {
+	if s.Method != "" && !strings.EqualFold(s.Method, method) {
+		return false
+	}
+
+	params, ok := MatchPath(s.PathTemplate, path)
+	if !ok {
+		return false
+	}
+
+	for name, want := range s.Headers {
+		got, ok := lookupHeader(req.Headers, name)
+		if !ok || got != want {
+			return false
+		}
+	}
+
+	for _, c := range s.BodyConditions {
+		if !c.holds(req.Body) {
+			return false
+		}
+	}
+
+	req.Path = params
+	return true
+}
+
+func lookupHeader(headers map[string]string, name string) (string, bool) {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (c BodyCondition) holds(body interface{}) bool {
+	v, found := Lookup(body, c.Path)
+
+	switch c.Op {
+	case OpExists:
+		return found
+	case OpAbsent:
+		return !found
+	}
+	if !found {
+		return false
+	}
+
+	s := stringify(v)
+	switch c.Op {
+	case OpEquals:
+		return s == c.Value
+	case OpContains:
+		return strings.Contains(s, c.Value)
+	case OpMatches:
+		ok, _ := regexp.MatchString(c.Value, s)
+		return ok
+	}
+	return false
+}
+
+func stringify(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return "null"
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// MatchPath matches path against a template where ":name" captures one
+// segment and a trailing "*" captures the rest (as param "*").
+func MatchPath(template, path string) (map[string]string, bool) {
+	tp := strings.Split(strings.Trim(template, "/"), "/")
+	sp := strings.Split(strings.Trim(path, "/"), "/")
+	params := map[string]string{}
+
+	for i, seg := range tp {
+		if seg == "*" && i == len(tp)-1 {
+			if i < len(sp) {
+				params["*"] = strings.Join(sp[i:], "/")
+			}
+			return params, true
+		}
+		if i >= len(sp) {
+			return nil, false
+		}
+		if strings.HasPrefix(seg, ":") {
+			params[seg[1:]] = sp[i]
+			continue
+		}
+		if seg != sp[i] {
+			return nil, false
+		}
+	}
+	if len(tp) != len(sp) {
+		return nil, false
+	}
+	return params, true
+}
+
+var indexPattern = regexp.MustCompile(`^([^\[]*)((?:\[\d+\])*)$`)
+
+// Lookup resolves a JSON path such as "$.order_items[0].quantity" or
+// "order_items.0.quantity" in a decoded JSON document.
+func Lookup(doc interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return doc, doc != nil
+	}
+
+	v := doc
+	for _, part := range strings.Split(path, ".") {
+		m := indexPattern.FindStringSubmatch(part)
+		if m == nil {
+			return nil, false
+		}
+
+		key, indexes := m[1], m[2]
+		if key != "" {
+			if i, err := strconv.Atoi(key); err == nil {
+				arr, ok := v.([]interface{})
+				if !ok || i < 0 || i >= len(arr) {
+					return nil, false
+				}
+				v = arr[i]
+			} else {
+				obj, ok := v.(map[string]interface{})
+				if !ok {
+					return nil, false
+				}
+				if v, ok = obj[key]; !ok {
+					return nil, false
+				}
+			}
+		}
+
+		for _, idx := range strings.Split(strings.Trim(indexes, "[]"), "][") {
+			if idx == "" {
+				continue
+			}
+			i, _ := strconv.Atoi(idx)
+			arr, ok := v.([]interface{})
+			if !ok || i >= len(arr) {
+				return nil, false
+			}
+			v = arr[i]
+		}
+	}
+	return v, true
+}
+
+// =======================
+// Response templates
+// =======================
+
+var templateFuncs = template.FuncMap{
+	// {{jsonPath .Body "$.order_items[0].quantity"}}
+	"jsonPath": func(doc interface{}, path string) interface{} {
+		v, _ := Lookup(doc, path)
+		return v
+	},
+	// {{json .Body}} renders a value as JSON
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"uuid": func() string { return uuid.NewString() },
+	// {{now}} is the server's (virtual) time in RFC3339
+	"now": func() string { return clock.Now().UTC().Format(time.RFC3339) },
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+}
+
+func parseTemplate(body string) (*template.Template, error) {
+	return template.New("stub").Funcs(templateFuncs).Option("missingkey=zero").Parse(body)
+}
+
+// ValidateTemplate checks that body parses as a response template
+func ValidateTemplate(body string) error {
+	_, err := parseTemplate(body)
+	return err
+}
+
+// Render executes the response body template against the request
+func (s *Stub) Render(req *Request) (string, error) {
+	return RenderTemplate(s.ResponseBody, req)
+}
+
+// RenderTemplate executes a response template against the request
+func RenderTemplate(body string, req *Request) (string, error) {
+	tmpl, err := parseTemplate(body)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, req); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
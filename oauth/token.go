@@ -0,0 +1,127 @@
+// Package oauth signs and verifies the HS256 JWT access tokens issued by
+// /oauth/token. Token times follow the virtual clock, so advancing it
+// expires tokens just like it expires delivery documents.
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shyamsundaar/karino-mock-server/clock"
+)
+
+// Issuer is the iss claim of every token
+const Issuer = "karino-mock-server"
+
+var (
+	ErrTokenInvalid = errors.New("token is malformed or has an invalid signature")
+	ErrTokenExpired = errors.New("token has expired")
+)
+
+// Claims carried by an access token
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"` // client_id
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+	ID        string   `json:"jti"`
+	Scope     string   `json:"scope"` // space separated
+	Coops     []string `json:"coops"`
+	KeyID     uint     `json:"key_id,omitempty"` // api_keys row the client authenticated with
+}
+
+// Scopes splits the scope claim
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+var b64 = base64.RawURLEncoding
+
+// header is fixed: HS256 is the only algorithm we issue or accept
+var header = b64.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Sign encodes and signs the claims
+func Sign(claims Claims, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + b64.EncodeToString(payload)
+	return unsigned + "." + b64.EncodeToString(sign(unsigned, secret)), nil
+}
+
+// Parse verifies the signature and expiry of token and returns its claims
+func Parse(token string, secret []byte) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenInvalid
+	}
+
+	// 1. Header must be exactly ours (no alg switching)
+	rawHeader, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if json.Unmarshal(rawHeader, &h) != nil || h.Alg != "HS256" {
+		return nil, ErrTokenInvalid
+	}
+
+	// 2. Signature
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, sign(parts[0]+"."+parts[1], secret)) {
+		return nil, ErrTokenInvalid
+	}
+
+	// 3. Claims
+	payload, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Issuer != Issuer {
+		return nil, ErrTokenInvalid
+	}
+	if !clock.Now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return &claims, ErrTokenExpired
+	}
+
+	return &claims, nil
+}
+
+func sign(unsigned string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+// TokenRequest is the client_credentials grant, form or JSON encoded
+type TokenRequest struct {
+	GrantType    string `json:"grant_type" form:"grant_type" example:"client_credentials"`
+	ClientID     string `json:"client_id" form:"client_id" example:"kmk_1a2b3c4d"`
+	ClientSecret string `json:"client_secret" form:"client_secret"`
+	Scope        string `json:"scope" form:"scope" example:"farmers:read salesorders:write"`
+	ExpiresIn    int64  `json:"expires_in" form:"expires_in" example:"60"` // shorter than the default lifetime, for refresh testing
+}
+
+// TokenResponse is the RFC 6749 access token response
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type" example:"Bearer"`
+	ExpiresIn   int64  `json:"expires_in" example:"3600"`
+	Scope       string `json:"scope" example:"farmers:read salesorders:write"`
+}
+
+// ErrorResponse is the RFC 6749 error response
+type ErrorResponse struct {
+	Error            string `json:"error" example:"invalid_client"`
+	ErrorDescription string `json:"error_description"`
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/swagger"
+	"github.com/shyamsundaar/karino-mock-server/controllers"
+	"github.com/shyamsundaar/karino-mock-server/initializers"
+	"github.com/shyamsundaar/karino-mock-server/middleware"
+)
+
+// Setup connects the database and starts the background services the
+// handlers rely on. Call it once before New.
+func Setup(config *initializers.Config) {
+	initializers.ConnectDB(config)
+	initializers.InitJournal(config)
+	initializers.InitOAuth(config)
+	initializers.InitSignatures(config)
+	initializers.InitPricing(config)
+	initializers.InitPhotoStore(config)
+
+	controllers.RegisterJobHandlers()
+	initializers.StartJobWorkers(initializers.DB)
+}
+
+// Config tweaks the app for tools that embed it
+type Config struct {
+	// DisableRequestLog drops the per-request access log line
+	DisableRequestLog bool
+}
+
+// New builds the Fiber app with all routes. It is shared by the server
+// binary and by tools that drive the app in-process (cmd/replay).
+func New(config ...Config) *fiber.App {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	// Photo uploads are larger than Fiber's default 4MB body limit
+	app := fiber.New(fiber.Config{BodyLimit: initializers.PhotoBodyLimit()})
+
+	// 1. Path Normalization Middleware
+	// This captures // and replaces it with / so the router doesn't 404
+	app.Use(func(c *fiber.Ctx) error {
+		path := c.Path()
+		if strings.Contains(path, "//") {
+			newPath := strings.ReplaceAll(path, "//", "/")
+			return c.Redirect(newPath, fiber.StatusMovedPermanently)
+		}
+		return c.Next()
+	})
+
+	if !cfg.DisableRequestLog {
+		app.Use(logger.New())
+	}
+	app.Use(cors.New(cors.Config{
+		AllowOrigins: "*",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, APIKey",
+		AllowMethods: "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+	}))
+
+	// Swagger Route
+	app.Get("/swagger/*", swagger.HandlerDefault)
+
+	micro := fiber.New()
+	app.Mount("/", micro)
+
+	// OAuth2 client credentials, for clients using Authorization: Bearer
+	micro.Post("/oauth/token", controllers.IssueTokenHandler)
+
+	micro.Route("/spic_to_erp", func(router fiber.Router) {
+		router.Use(middleware.Journal)
+		router.Use(middleware.Auth)
+		router.Use(middleware.SignatureVerification)
+		router.Use(middleware.JSONProviderMiddleware)
+		router.Use(middleware.Scenarios)
+		router.Use(middleware.FaultInjection)
+
+		router.Route("/customers", func(router fiber.Router) {
+
+			// Grouping by coopId to keep it clean
+			router.Route("/:coopId", func(cust fiber.Router) {
+
+				// Farmer Routes
+				cust.Post("/farmers", controllers.CreateCustomerDetailHandler)
+				cust.Get("/farmers", controllers.FindCustomerDetailsHandler)
+				cust.Get("/farmers/:farmerId", controllers.GetCustomerDetailHandler)
+				cust.Patch("/farmers/:farmerId", controllers.PatchCustomerDetailHandler)
+				cust.Post("/farmers/:farmerId/deactivate", controllers.DeactivateCustomerDetailHandler)
+				cust.Post("/farmers/:farmerId/reactivate", controllers.ReactivateCustomerDetailHandler)
+
+				// Sales Orders Group
+				cust.Route("/salesorders", func(sales fiber.Router) {
+					// STATIC ROUTES FIRST
+					// Matches: /salesorders/deliverydocuments
+					sales.Post("/deliverydocuments", controllers.CreateCustomerDeliveryDocumentDetailsHandler)
+					sales.Get("/deliverydocuments", controllers.GetCustomerDeliveryDocumentDetailHandler)
+
+					// PARAMETRIC ROUTES SECOND
+					// Matches: /salesorders/:orderId
+					sales.Get("/:orderId", controllers.GetCustomerSalesOrderDetailsHandler)
+					sales.Put("/:orderId", controllers.UpdateCustomerSalesOrderHandler)
+					sales.Patch("/:orderId", controllers.PatchCustomerSalesOrderHandler)
+					sales.Post("/:orderId/cancel", controllers.CancelCustomerSalesOrderHandler)
+					sales.Get("/:orderId/history", controllers.GetSalesOrderStatusHistoryHandler)
+					// Matches: /salesorders/:orderId/deliverydocuments
+					sales.Get("/:orderId/deliverydocuments", controllers.GetDeliveryDetailParticularHandler)
+
+					// Base Sales Order Routes
+					sales.Post("/", controllers.CreateCustomerSalesOrderHandler)
+					sales.Get("/", controllers.GetCustomerSalesDetailHandler)
+				})
+
+				// Delivery Proof Routes
+				cust.Post("/deliverydocuments/:deliveryNoteId/proof", controllers.CreateDeliveryDocumentsProofHandler)
+				cust.Post("/deliverydocuments/:deliveryNoteId/void", controllers.VoidDeliveryDocumentHandler)
+				cust.Post("/deliverydocuments/:deliveryNoteId/reissue", controllers.ReissueDeliveryDocumentHandler)
+				cust.Get("/deliverydocuments/invoices", controllers.GetDeliveryDocumentsProofHandler)
+				cust.Get("/deliverydocuments/:deliveryNoteId/invoices", controllers.GetDeliveryDocumentsProofParticularHandler)
+				cust.Post("/deliverydocuments/:deliveryNoteId/photos", controllers.AddDeliveryPhotosHandler)
+				cust.Get("/deliverydocuments/:deliveryNoteId/photos", controllers.GetDeliveryPhotosHandler)
+				cust.Get("/deliverydocuments/:deliveryNoteId/photos/:photoId", controllers.DownloadDeliveryPhotoHandler)
+			})
+		})
+
+		router.Route("/vendors", func(router fiber.Router) {
+			router.Route("/:coopId", func(vend fiber.Router) {
+				vend.Post("/farmers", controllers.CreateVendorDetailHandler)
+				vend.Get("/farmers", controllers.FindVendorDetailsHandler)
+				vend.Get("/farmers/:farmerId", controllers.GetVendorDetailHandler)
+				vend.Patch("/farmers/:farmerId", controllers.PatchVendorDetailHandler)
+				vend.Post("/farmers/:farmerId/deactivate", controllers.DeactivateVendorDetailHandler)
+				vend.Post("/farmers/:farmerId/reactivate", controllers.ReactivateVendorDetailHandler)
+			})
+		})
+	})
+
+	// Admin / test-support routes
+	micro.Route("/admin", func(router fiber.Router) {
+		router.Use(middleware.AdminKeyAuth)
+
+		router.Get("/jobs", controllers.ListJobsHandler)
+		router.Get("/jobs/:jobId", controllers.GetJobHandler)
+		router.Post("/jobs/:jobId/retry", controllers.RetryJobHandler)
+		router.Post("/jobs/:jobId/run", controllers.RunJobHandler)
+
+		router.Get("/clock", controllers.GetClockHandler)
+		router.Post("/clock/freeze", controllers.FreezeClockHandler)
+		router.Post("/clock/resume", controllers.ResumeClockHandler)
+		router.Post("/clock/set", controllers.SetClockHandler)
+		router.Post("/clock/advance", controllers.AdvanceClockHandler)
+		router.Post("/clock/reset", controllers.ResetClockHandler)
+
+		router.Get("/faults", controllers.ListFaultsHandler)
+		router.Post("/faults", controllers.CreateFaultHandler)
+		router.Delete("/faults", controllers.ClearFaultsHandler)
+		router.Get("/faults/:faultId", controllers.GetFaultHandler)
+		router.Delete("/faults/:faultId", controllers.DeleteFaultHandler)
+
+		router.Get("/journal", controllers.SearchJournalHandler)
+		router.Delete("/journal", controllers.ClearJournalHandler)
+		router.Get("/journal/:entryId", controllers.GetJournalEntryHandler)
+
+		router.Get("/stubs", controllers.ListStubsHandler)
+		router.Post("/stubs", controllers.CreateStubHandler)
+		router.Delete("/stubs", controllers.ClearStubsHandler)
+		router.Get("/stubs/:stubId", controllers.GetStubHandler)
+		router.Put("/stubs/:stubId", controllers.UpdateStubHandler)
+		router.Delete("/stubs/:stubId", controllers.DeleteStubHandler)
+
+		router.Get("/scenarios", controllers.ListScenariosHandler)
+		router.Post("/scenarios", controllers.CreateScenarioHandler)
+		router.Post("/scenarios/reset", controllers.ResetAllScenariosHandler)
+		router.Get("/scenarios/:name", controllers.GetScenarioHandler)
+		router.Put("/scenarios/:name", controllers.UpdateScenarioHandler)
+		router.Delete("/scenarios/:name", controllers.DeleteScenarioHandler)
+		router.Put("/scenarios/:name/state", controllers.SetScenarioStateHandler)
+		router.Post("/scenarios/:name/reset", controllers.ResetScenarioHandler)
+
+		router.Get("/products", controllers.ListProductsHandler)
+		router.Post("/products", controllers.CreateProductHandler)
+		router.Get("/products/:productCode", controllers.GetProductHandler)
+		router.Put("/products/:productCode", controllers.UpdateProductHandler)
+		router.Delete("/products/:productCode", controllers.DeleteProductHandler)
+
+		router.Get("/pricelist", controllers.ListPriceListHandler)
+		router.Put("/pricelist/:productCode", controllers.SetPriceHandler)
+
+		router.Get("/taxrules", controllers.ListTaxRulesHandler)
+		router.Post("/taxrules", controllers.CreateTaxRuleHandler)
+		router.Get("/taxrules/:ruleId", controllers.GetTaxRuleHandler)
+		router.Put("/taxrules/:ruleId", controllers.UpdateTaxRuleHandler)
+		router.Delete("/taxrules/:ruleId", controllers.DeleteTaxRuleHandler)
+
+		router.Get("/cooperatives", controllers.ListCooperativesHandler)
+		router.Post("/cooperatives", controllers.CreateCooperativeHandler)
+		router.Get("/cooperatives/:coopId", controllers.GetCooperativeHandler)
+		router.Put("/cooperatives/:coopId", controllers.UpdateCooperativeHandler)
+		router.Delete("/cooperatives/:coopId", controllers.DeleteCooperativeHandler)
+
+		router.Get("/apikeys", controllers.ListAPIKeysHandler)
+		router.Post("/apikeys", controllers.CreateAPIKeyHandler)
+		router.Get("/apikeys/:keyId", controllers.GetAPIKeyHandler)
+		router.Put("/apikeys/:keyId", controllers.UpdateAPIKeyHandler)
+		router.Delete("/apikeys/:keyId", controllers.DeleteAPIKeyHandler)
+		router.Post("/apikeys/:keyId/revoke", controllers.RevokeAPIKeyHandler)
+	})
+
+	// Runtime stubs answer anything the routes above don't, before the 404
+	micro.Use(controllers.StubHandler)
+
+	return app
+}